@@ -2,13 +2,142 @@ package analyzer
 
 import (
 	"fmt"
+	"maps"
 
 	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/token"
 	"github.com/flowtemplates/flow-go/types"
 )
 
 type TypeMap map[string]types.Type
 
+// Types expected by the use of the value, they are refined by other uses
+// of the value and replaced by [concrete] if there are none.
+const (
+	// Any value can be used as a condition, its type becomes boolean
+	condition types.PrimitiveType = "condition"
+	// Strings, numbers and booleans are printed by expression tags,
+	// the type of the value becomes string
+	printed types.PrimitiveType = "printed"
+)
+
+// concrete replaces the types expected by uses of the value with the types
+// of values used only that way.
+func concrete(typ types.Type) types.Type {
+	switch t := typ.(type) {
+	case types.ListType:
+		return types.ListType{Elem: concrete(t.Elem)}
+
+	case types.ObjectType:
+		fields := make(map[string]types.Type, len(t.Fields))
+		for name, fieldType := range t.Fields {
+			fields[name] = concrete(fieldType)
+		}
+
+		return types.ObjectType{Fields: fields}
+	}
+
+	switch typ {
+	case condition:
+		return types.Boolean
+
+	case printed:
+		return types.String
+	}
+
+	return typ
+}
+
+// unify returns the most specific type that satisfies both current and typ.
+// Structural types are unified field by field, so object types inferred from
+// different member accesses are merged into one.
+func unify(current, typ types.Type) (types.Type, bool) {
+	switch {
+	case current == types.Any:
+		return typ, true
+
+	case typ == types.Any:
+		return current, true
+
+	case current == condition:
+		return typ, true
+
+	case typ == condition:
+		return current, true
+
+	case current == printed:
+		return typ, isPrintable(typ)
+
+	case typ == printed:
+		return current, isPrintable(current)
+	}
+
+	switch c := current.(type) {
+	case types.ListType:
+		t, ok := typ.(types.ListType)
+		if !ok {
+			return nil, false
+		}
+
+		elem, ok := unify(c.Elem, t.Elem)
+		if !ok {
+			return nil, false
+		}
+
+		return types.ListType{Elem: elem}, true
+
+	case types.ObjectType:
+		t, ok := typ.(types.ObjectType)
+		if !ok {
+			return nil, false
+		}
+
+		fields := maps.Clone(c.Fields)
+		for name, fieldType := range t.Fields {
+			if currentField, exists := fields[name]; exists {
+				if fieldType, ok = unify(currentField, fieldType); !ok {
+					return nil, false
+				}
+			}
+
+			fields[name] = fieldType
+		}
+
+		return types.ObjectType{Fields: fields}, true
+
+	default:
+		if _, ok := typ.(types.ObjectType); ok {
+			return nil, false
+		}
+
+		return current, current == typ
+	}
+}
+
+func isPrintable(typ types.Type) bool {
+	_, ok := typ.(types.PrimitiveType)
+
+	return ok
+}
+
+// Resolve follows variable types to the type they are bound to.
+func (tm TypeMap) Resolve(typ types.Type) types.Type {
+	// Bounded by the size of the map in case variables are bound to each other
+	for range len(tm) + 1 {
+		varType, ok := typ.(types.VarType)
+		if !ok {
+			return typ
+		}
+
+		if typ, ok = tm[string(varType)]; !ok {
+			return types.Any
+		}
+	}
+
+	return types.Any
+}
+
 func (tm TypeMap) getPrimitive(typ types.Type) *types.PrimitiveType {
 	if primmitiveType, ok := typ.(types.PrimitiveType); ok {
 		return &primmitiveType
@@ -24,8 +153,6 @@ func (tm TypeMap) getPrimitive(typ types.Type) *types.PrimitiveType {
 			return &primmitiveType
 		}
 
-		fmt.Println("recursive get primitive")
-
 		return tm.getPrimitive(t)
 	}
 
@@ -44,29 +171,37 @@ func (tm TypeMap) addToTypeMap(name string, typ types.Type) (types.Type, *TypeEr
 		return tm.addToTypeMap(string(varType), typ)
 	}
 
-	switch {
-	case !exists || current == types.Any:
+	if !exists {
 		tm[name] = typ
 
-	case current == types.Boolean:
-		if typ != types.Any && typ != types.Boolean {
-			tm[name] = typ
+		return types.VarType(name), nil
+	}
+
+	unified, ok := unify(current, typ)
+	if !ok {
+		var expected types.Type = typ
+		if prim := tm.getPrimitive(typ); prim != nil {
+			expected = *prim
 		}
 
-	case typ != types.Any && current != typ:
+		expected = concrete(expected)
+
 		return nil, &TypeError{
 			Name:         name,
-			ExpectedType: *tm.getPrimitive(typ),
+			ExpectedType: expected,
 		}
 	}
 
+	tm[name] = unified
+
 	return types.VarType(name), nil
 }
 
-func (a *Analyzer) equalTypes(typ1, typ2 types.Type) types.Type {
+func (a *Analyzer) equalTypes(pos token.Position, typ1, typ2 types.Type) types.Type {
 	if xVarType, ok1 := typ1.(types.VarType); ok1 {
 		t, err := a.Tm.addToTypeMap(string(xVarType), typ2)
 		if err != nil {
+			err.Pos = pos
 			a.Errs.Add(err)
 		}
 
@@ -80,41 +215,177 @@ func (a *Analyzer) parseNodes(ast []parser.Node) {
 	for _, node := range ast {
 		switch n := node.(type) {
 		case *parser.ExprNode:
-			a.parseExpressionTypes(n.Body, types.String)
+			a.parseExpressionTypes(n.Body, printed)
+
+		case *parser.GenifNode:
+			a.parseExpressionTypes(n.Expr, condition)
+
+		case *parser.LetNode:
+			a.locals[len(a.locals)-1][n.Name.Name] = a.parseExpressionTypes(n.Expr, types.Any)
 
 		case *parser.IfNode:
-			a.parseExpressionTypes(n.IfTag.Expr, types.Boolean)
-			a.parseNodes(n.Main)
+			a.parseExpressionTypes(n.IfTag.Expr, condition)
+			a.parseBlock(n.Main, nil)
 
 			for _, elseIf := range n.ElseIfs {
-				a.parseExpressionTypes(elseIf.Tag.Expr, types.Boolean)
-				a.parseNodes(elseIf.Body)
+				a.parseExpressionTypes(elseIf.Tag.Expr, condition)
+				a.parseBlock(elseIf.Body, nil)
 			}
 
-			a.parseExpressionTypes(n.IfTag.Expr, types.Boolean)
-			a.parseNodes(n.Main)
+			a.parseBlock(n.Else.Body, nil)
+
+		case *parser.ForNode:
+			a.parseForNode(n)
+
+		case *parser.BlockNode:
+			a.parseBlock(n.Body, nil)
 
 		case *parser.SwitchNode:
 			switchType := a.parseExpressionTypes(n.SwitchTag.Expr, types.Any)
 
 			for _, c := range n.Cases {
 				caseTyp := a.parseExpressionTypes(c.Tag.Expr, switchType)
-				a.equalTypes(switchType, caseTyp)
-				a.parseNodes(c.Body)
+				a.equalTypes(c.Tag.Expr.Pos(), switchType, caseTyp)
+				a.parseBlock(c.Body, nil)
 			}
 
 			if n.DefaultCase != nil {
-				a.parseNodes(n.DefaultCase.Body)
+				a.parseBlock(n.DefaultCase.Body, nil)
 			}
 		}
 	}
 }
 
+// parseBlock analyzes nodes in a new block scope with given variables declared.
+func (a *Analyzer) parseBlock(ast []parser.Node, locals map[string]types.Type) {
+	if locals == nil {
+		locals = make(map[string]types.Type)
+	}
+
+	a.locals = append(a.locals, locals)
+	a.parseNodes(ast)
+	a.locals = a.locals[:len(a.locals)-1]
+}
+
+// lookupLocal refines type of the block scoped variable if it is declared.
+func (a *Analyzer) lookupLocal(pos token.Position, name string, typ types.Type) (types.Type, bool) {
+	for i := len(a.locals) - 1; i >= 0; i-- {
+		current, ok := a.locals[i][name]
+		if !ok {
+			continue
+		}
+
+		// Variable bound to an input, e.g. {% let a = b %}
+		if varType, ok := current.(types.VarType); ok {
+			t, err := a.Tm.addToTypeMap(string(varType), typ)
+			if err != nil {
+				err.Pos = pos
+				a.Errs.Add(err)
+			}
+
+			return t, true
+		}
+
+		if unified, ok := unify(current, typ); ok {
+			a.locals[i][name] = unified
+
+			return unified, true
+		}
+
+		return current, true
+	}
+
+	return nil, false
+}
+
+func (a *Analyzer) parseForNode(n *parser.ForNode) {
+	locals := map[string]types.Type{
+		n.ForTag.Value.Name: types.Any,
+	}
+
+	if n.ForTag.Key != nil {
+		locals[n.ForTag.Key.Name] = types.Number
+	}
+
+	a.parseBlock(n.Body, locals)
+	a.parseExpressionTypes(n.ForTag.Expr, types.ListType{Elem: locals[n.ForTag.Value.Name]})
+	a.parseBlock(n.Else.Body, nil)
+}
+
+// parseFilterExpr checks that the filter is declared and applied to the value
+// and arguments of matching types, returns the type of the filter result.
+func (a *Analyzer) parseFilterExpr(e *parser.FilterExpr) types.Type {
+	name := e.Filter.Name
+
+	filter, ok := a.Filters[name]
+	if !ok {
+		a.parseExpressionTypes(e.Expr, types.Any)
+		a.Errs.Add(&TypeError{
+			Pos:    e.Filter.Pos(),
+			Name:   name,
+			Reason: fmt.Sprintf("filter '%s' is not declared", name),
+		})
+
+		return types.Any
+	}
+
+	a.parseExpressionTypes(e.Expr, orAny(filter.Input))
+	a.parseFilterArgs(e, filter)
+
+	return orAny(filter.Output)
+}
+
+func orAny(typ types.Type) types.Type {
+	if typ == nil {
+		return types.Any
+	}
+
+	return typ
+}
+
+func (a *Analyzer) parseFilterArgs(e *parser.FilterExpr, filter renderer.Filter) {
+	name := e.Filter.Name
+
+	if len(e.Args) != len(filter.Params) {
+		a.Errs.Add(&TypeError{
+			Pos:    e.Filter.Pos(),
+			Name:   name,
+			Reason: fmt.Sprintf("filter '%s' expects %d arguments, got %d", name, len(filter.Params), len(e.Args)),
+		})
+
+		return
+	}
+
+	for i, arg := range e.Args {
+		param := filter.Params[i]
+
+		// Variables are unified with the parameter type, only literals are left
+		t, ok := a.parseExpressionTypes(arg, param).(types.PrimitiveType)
+		if !ok {
+			continue
+		}
+
+		if _, ok := unify(t, param); !ok {
+			a.Errs.Add(&TypeError{
+				Pos:          arg.Pos(),
+				ExpectedType: param,
+				Name:         name,
+				Reason:       fmt.Sprintf("argument %d of filter '%s' expected type '%s'", i+1, name, param),
+			})
+		}
+	}
+}
+
 func (a *Analyzer) parseExpressionTypes(expr parser.Expr, typ types.Type) types.Type {
 	switch e := expr.(type) {
 	case *parser.Ident:
+		if t, ok := a.lookupLocal(e.Pos(), e.Name, typ); ok {
+			return t
+		}
+
 		t, err := a.Tm.addToTypeMap(e.Name, typ)
 		if err != nil {
+			err.Pos = e.Pos()
 			a.Errs.Add(err)
 		}
 
@@ -123,29 +394,93 @@ func (a *Analyzer) parseExpressionTypes(expr parser.Expr, typ types.Type) types.
 	case *parser.StringLit:
 		return e.Value.Type()
 
+	case *parser.InterpolatedLit:
+		for _, part := range e.Parts {
+			a.parseExpressionTypes(part, types.String)
+		}
+
+		return types.String
+
 	case *parser.NumberLit:
 		return e.Value.Type()
 
 	case *parser.FilterExpr:
-		a.parseExpressionTypes(e.Expr, types.String)
+		return a.parseFilterExpr(e)
 
 	case *parser.TernaryExpr:
-		a.parseExpressionTypes(e.Condition, types.Boolean)
+		a.parseExpressionTypes(e.Condition, condition)
 		a.parseExpressionTypes(e.TrueExpr, typ)
 		a.parseExpressionTypes(e.FalseExpr, typ)
 
 	case *parser.ParenExpr:
 		a.parseExpressionTypes(e.Expr, typ)
 
-	case *parser.BinaryExpr:
-		if e.Op.Kind.IsLogicalOp() {
-			a.parseExpressionTypes(e.X, types.Boolean)
-			a.parseExpressionTypes(e.Y, types.Boolean)
+	case *parser.SelectorExpr:
+		a.parseExpressionTypes(e.X, types.ObjectType{
+			Fields: map[string]types.Type{e.Sel.Name: typ},
+		})
+
+	case *parser.IndexExpr:
+		if lit, ok := e.Index.(*parser.StringLit); ok {
+			a.parseExpressionTypes(e.X, types.ObjectType{
+				Fields: map[string]types.Type{lit.Value.AsString(): typ},
+			})
+
+			break
+		}
+
+		index := a.parseExpressionTypes(e.Index, types.Any)
+		if prim := a.Tm.getPrimitive(index); prim != nil && *prim == types.Number {
+			a.parseExpressionTypes(e.X, types.ListType{Elem: typ})
 		} else {
+			a.parseExpressionTypes(e.X, types.Any)
+		}
+
+	case *parser.UnaryExpr:
+		switch e.Op.Kind {
+		case token.MINUS:
+			a.parseExpressionTypes(e.Expr, types.Number)
+
+			return types.Number
+
+		case token.NOT, token.EXCL:
+			a.parseExpressionTypes(e.Expr, condition)
+
+			return types.Boolean
+		}
+
+	case *parser.BinaryExpr:
+		switch {
+		case e.Op.Kind.IsLogicalOp():
+			a.parseExpressionTypes(e.X, condition)
+			a.parseExpressionTypes(e.Y, condition)
+
+		case e.Op.Kind == token.ADD:
+			// Addition of numbers or concatenation of strings
+			x := a.parseExpressionTypes(e.X, types.Any)
+			y := a.parseExpressionTypes(e.Y, types.Any)
+
+			if x == types.String || y == types.String {
+				return types.String
+			}
+
+			if t := a.equalTypes(e.Op.Pos, x, y); t != nil {
+				return t
+			}
+
+			return y
+
+		case e.Op.Kind.IsArithmeticOp():
+			a.parseExpressionTypes(e.X, types.Number)
+			a.parseExpressionTypes(e.Y, types.Number)
+
+			return types.Number
+
+		default:
 			x := a.parseExpressionTypes(e.X, types.Any)
 			y := a.parseExpressionTypes(e.Y, types.Any)
 
-			return a.equalTypes(x, y)
+			return a.equalTypes(e.Op.Pos, x, y)
 		}
 	}
 
@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/flowtemplates/flow-go/token"
 	"github.com/flowtemplates/flow-go/types"
 )
 
 type TypeError struct {
-	ExpectedType types.PrimitiveType
+	Pos          token.Position
+	ExpectedType types.Type
 	Name         string
+	// Reason describes errors not caused by the variable type mismatch,
+	// e.g. call of the undeclared filter. Name is a name of the filter then.
+	Reason string `json:",omitempty"`
 }
 
 //	func (e *TypeError) String() string {
@@ -17,9 +22,18 @@ type TypeError struct {
 //	}
 
 func (e TypeError) Error() string {
+	if e.Reason != "" {
+		return "TypeError: " + e.Reason
+	}
+
 	return fmt.Sprintf("TypeError: Variable '%s' expected type '%s'", e.Name, e.ExpectedType)
 }
 
+// Position returns position of the expression where the error was found.
+func (e TypeError) Position() token.Position {
+	return e.Pos
+}
+
 type TypeErrors []TypeError //nolint: recvcheck
 
 func (l TypeErrors) Error() string {
@@ -39,6 +53,16 @@ func (l TypeErrors) Error() string {
 	return strings.Join(b, ", ")
 }
 
+// Unwrap returns errors of the list, so each of them can be inspected.
+func (l TypeErrors) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+
+	return errs
+}
+
 // Err returns an error equivalent to this error list.
 // If the list is empty, Err returns nil.
 func (l TypeErrors) Err() error {
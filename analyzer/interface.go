@@ -5,17 +5,26 @@ import (
 
 	"github.com/flowtemplates/flow-go/parser"
 	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/types"
 )
 
 type Analyzer struct {
 	Tm   TypeMap
 	Errs TypeErrors
+	// Filters available in templates, must be the same as in
+	// [renderer.Environment] used for rendering.
+	Filters renderer.Filters
+	// Stack of block scopes with variables declared inside the template,
+	// e.g. loop variables and let bindings. They are not a part of the input,
+	// so they never get into Tm.
+	locals []map[string]types.Type
 }
 
 func New() *Analyzer {
 	return &Analyzer{
-		Tm:   TypeMap{},
-		Errs: TypeErrors{},
+		Tm:      TypeMap{},
+		Errs:    TypeErrors{},
+		Filters: renderer.DefaultFilters(),
 	}
 }
 
@@ -23,17 +32,18 @@ func Typecheck(scope renderer.Input, tm TypeMap) []TypeError {
 	errs := []TypeError{}
 
 	for name, typ := range tm {
-		prim := tm.getPrimitive(typ)
-		if prim == nil {
+		value, ok := scope[name]
+		if !ok {
+			if prim := tm.getPrimitive(typ); prim != nil {
+				scope[name] = prim
+			}
+
 			continue
 		}
 
-		value, ok := scope[name]
-		if !ok {
-			scope[name] = prim
-		} else if !prim.IsValid(value) {
+		if expected := tm.Resolve(typ); !types.IsValid(expected, value) {
 			errs = append(errs, TypeError{
-				ExpectedType: *prim,
+				ExpectedType: expected,
 				Name:         name,
 			})
 		}
@@ -48,7 +58,11 @@ func Typecheck(scope renderer.Input, tm TypeMap) []TypeError {
 
 // TODO: make func that returns TypeMap and TypeErrors
 func (a *Analyzer) TypeMapFromAst(ast []parser.Node) {
-	a.parseNodes(ast)
+	a.parseBlock(ast, nil)
+
+	for name, typ := range a.Tm {
+		a.Tm[name] = concrete(typ)
+	}
 	//	if len(errs) > 0 {
 	//		return &errs
 	//	}
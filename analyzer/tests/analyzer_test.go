@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/renderer"
 	"github.com/flowtemplates/flow-go/types"
 )
 
@@ -28,6 +29,14 @@ func TestGetTypeMap(t *testing.T) {
 				"name": types.String,
 			},
 		},
+		{
+			name:  "Interpolated vars",
+			input: `{{ "${ name -> upper } is ${ age + 1 }" }}`,
+			expected: analyzer.TypeMap{
+				"name": types.String,
+				"age":  types.Number,
+			},
+		},
 		{
 			name:  "Var equal var",
 			input: "{{ name == surname }}",
@@ -278,6 +287,27 @@ text
 				"var": types.Number,
 			},
 		},
+		{
+			name:  "Number condition printed",
+			input: "{% if count > 0 %}{{ count }} items{% end %}",
+			expected: analyzer.TypeMap{
+				"count": types.Number,
+			},
+		},
+		{
+			name:  "Printed var used as condition",
+			input: "{{ name }}{% if name %}!{% end %}",
+			expected: analyzer.TypeMap{
+				"name": types.String,
+			},
+		},
+		{
+			name:  "Printed var used as number",
+			input: "{{ n }} {{ n + 1 }}",
+			expected: analyzer.TypeMap{
+				"n": types.Number,
+			},
+		},
 		{
 			name: "Else-if statement on var",
 			input: `
@@ -343,6 +373,262 @@ text123
 				},
 			},
 		},
+		{
+			name: "For statement",
+			input: `
+{% for item in items %}
+{{ item }}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"items": types.ListType{Elem: types.String},
+			},
+		},
+		{
+			name: "For statement with unused item",
+			input: `
+{% for i, item in items %}
+{{ i }}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"items": types.ListType{Elem: types.Any},
+			},
+		},
+		{
+			name: "For statement with item used as boolean",
+			input: `
+{% for item in items %}
+{% if item and flag %}
+{% end %}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"items": types.ListType{Elem: types.Boolean},
+				"flag":  types.Boolean,
+			},
+		},
+		{
+			name: "Let statement is not an input",
+			input: `
+{% let a = 1 %}
+{% if a == b %}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"b": types.Any,
+			},
+		},
+		{
+			name: "Let statement bound to input",
+			input: `
+{% let a = name %}
+{{ a }}
+`[1:],
+			expected: analyzer.TypeMap{
+				"name": types.String,
+			},
+		},
+		{
+			name: "Let statement inside if does not leak",
+			input: `
+{% if flag %}
+{% let a = 1 %}
+{% end %}
+{{ a }}
+`[1:],
+			expected: analyzer.TypeMap{
+				"flag": types.Boolean,
+				"a":    types.String,
+			},
+		},
+		{
+			name:  "Arithmetic",
+			input: "{{ a * b - -c }}",
+			expected: analyzer.TypeMap{
+				"a": types.Number,
+				"b": types.Number,
+				"c": types.Number,
+			},
+		},
+		{
+			name:  "Addition of var and number",
+			input: "{{ index + 1 }}",
+			expected: analyzer.TypeMap{
+				"index": types.Number,
+			},
+		},
+		{
+			name:  "Concatenation of var and string",
+			input: "{{ 'get_' + name }}",
+			expected: analyzer.TypeMap{
+				"name": types.Any,
+			},
+		},
+		{
+			name:  "Member access",
+			input: "{{ user.name }} {{ items[i] }}",
+			expected: analyzer.TypeMap{
+				"user": types.ObjectType{
+					Fields: map[string]types.Type{"name": types.String},
+				},
+				"items": types.Any,
+				"i":     types.Any,
+			},
+		},
+		{
+			name: "Member access merges fields",
+			input: `
+{{ user.name }}
+{% if user.admin %}
+{% end %}
+{{ user.address["city"] }}
+`[1:],
+			expected: analyzer.TypeMap{
+				"user": types.ObjectType{
+					Fields: map[string]types.Type{
+						"name":  types.String,
+						"admin": types.Boolean,
+						"address": types.ObjectType{
+							Fields: map[string]types.Type{"city": types.String},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Index by number",
+			input: "{{ items[0] }} {{ items[i + 1] }}",
+			expected: analyzer.TypeMap{
+				"items": types.ListType{Elem: types.String},
+				"i":     types.Number,
+			},
+		},
+		{
+			name: "For statement over objects",
+			input: `
+{% for user in users %}
+{{ user.name }}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"users": types.ListType{
+					Elem: types.ObjectType{
+						Fields: map[string]types.Type{"name": types.String},
+					},
+				},
+			},
+		},
+		{
+			name: "Object used as string",
+			input: `
+{{ user.name }}
+{{ user }}
+`[1:],
+			errExpected: analyzer.TypeErrors{
+				{
+					ExpectedType: types.String,
+					Name:         "user",
+				},
+			},
+		},
+		{
+			name:  "Filter arguments",
+			input: "{{ name -> replace(from, '_') -> truncate(n) }}",
+			expected: analyzer.TypeMap{
+				"name": types.String,
+				"from": types.String,
+				"n":    types.Number,
+			},
+		},
+		{
+			name:  "Filter argument of wrong type",
+			input: "{{ name -> truncate('a') }}",
+			errExpected: analyzer.TypeErrors{
+				{
+					ExpectedType: types.Number,
+					Name:         "truncate",
+					Reason:       "argument 1 of filter 'truncate' expected type 'number'",
+				},
+			},
+		},
+		{
+			name:  "Filter with wrong number of arguments",
+			input: "{{ name -> replace('a') }}",
+			errExpected: analyzer.TypeErrors{
+				{
+					Name:   "replace",
+					Reason: "filter 'replace' expects 2 arguments, got 1",
+				},
+			},
+		},
+		{
+			name: "Filter input and output types",
+			input: `
+{{ items -> join(", ") }}
+{% if (count -> length) > 1 %}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"items": types.ListType{Elem: types.Any},
+				"count": types.Any,
+			},
+		},
+		{
+			name:  "Custom filter",
+			input: "{{ field -> goType(pkg) }}",
+			filters: renderer.Filters{
+				"goType": {
+					Input:  types.ObjectType{Fields: map[string]types.Type{"type": types.String}},
+					Output: types.String,
+					Params: []types.Type{types.Boolean},
+				},
+			},
+			expected: analyzer.TypeMap{
+				"field": types.ObjectType{Fields: map[string]types.Type{"type": types.String}},
+				"pkg":   types.Boolean,
+			},
+		},
+		{
+			name:  "Undeclared filter",
+			input: "{{ name -> nope }}",
+			errExpected: analyzer.TypeErrors{
+				{
+					Name:   "nope",
+					Reason: "filter 'nope' is not declared",
+				},
+			},
+		},
+		{
+			name: "Else statement",
+			input: `
+{% if a %}
+{{ b }}
+{% else %}
+{{ c }}
+{% end %}
+`[1:],
+			expected: analyzer.TypeMap{
+				"a": types.Boolean,
+				"b": types.String,
+				"c": types.String,
+			},
+		},
+		{
+			name:  "Negation",
+			input: "{% if !a or not b %}{% end %}",
+			expected: analyzer.TypeMap{
+				"a": types.Boolean,
+				"b": types.Boolean,
+			},
+		},
+		{
+			name:  "Genif statement",
+			input: "{% genif flag %}",
+			expected: analyzer.TypeMap{
+				"flag": types.Boolean,
+			},
+		},
 	}
 	runTestCases(t, testCases)
 }
@@ -6,11 +6,13 @@ import (
 	"testing"
 
 	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/renderer"
 )
 
 type testCase struct {
 	name        string
 	input       string
+	filters     renderer.Filters
 	expected    analyzer.TypeMap
 	errExpected analyzer.TypeErrors
 }
@@ -21,6 +23,9 @@ func runTestCases(t *testing.T, testCases []testCase) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			a := analyzer.New()
+			if tc.filters != nil {
+				a.Filters = tc.filters
+			}
 
 			err := a.TypeMapFromBytes([]byte(tc.input))
 
@@ -1,5 +1,13 @@
 package analyzer_test
 
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/types"
+)
+
 // import (
 // 	"slices"
 // 	"testing"
@@ -109,3 +117,71 @@ package analyzer_test
 // 		})
 // 	}
 // }
+
+func TestTypecheckStructured(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Admin bool
+		email string
+	}
+
+	tm := analyzer.TypeMap{
+		"users": types.ListType{
+			Elem: types.ObjectType{
+				Fields: map[string]types.Type{
+					"name":  types.String,
+					"Admin": types.Boolean,
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		input       renderer.Input
+		expectedErr bool
+	}{
+		{
+			name: "Slice of structs",
+			input: renderer.Input{
+				"users": []user{{Name: "a", Admin: true, email: "a@a"}},
+			},
+		},
+		{
+			name: "Slice of maps",
+			input: renderer.Input{
+				"users": []map[string]any{{"name": "a", "Admin": false}},
+			},
+		},
+		{
+			name: "Missing field",
+			input: renderer.Input{
+				"users": []map[string]any{{"name": "a"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Wrong field type",
+			input: renderer.Input{
+				"users": []map[string]any{{"name": 1, "Admin": false}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Not a list",
+			input: renderer.Input{
+				"users": user{Name: "a"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := analyzer.Typecheck(tc.input, tm)
+			if (len(errs) != 0) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/token"
+	"github.com/flowtemplates/flow-go/types"
+)
+
+// Keywords starting statements
+var stmtKeywords = []token.Kind{
+	token.IF, token.ELSE, token.FOR, token.LET, token.GENIF, token.SWITCH,
+	token.CASE, token.DEFAULT, token.END, token.EXTEND, token.BLOCK, token.RAW,
+}
+
+// completion suggests names by the tokens before the offset: filters after
+// '->', fields after '.', statement keywords at the start of the statement
+// tag and variables elsewhere inside tags.
+func (s *server) completion(doc *document, offset int) []CompletionItem {
+	// Tokens before the offset
+	i := sort.Search(len(doc.tokens), func(i int) bool {
+		return doc.tokens[i].Pos.Offset >= offset
+	})
+	before := doc.tokens[:i]
+
+	// The name being typed is replaced by the completion
+	if n := len(before); n != 0 && before[n-1].End().Offset == offset &&
+		(before[n-1].Kind == token.IDENT || before[n-1].Kind.IsKeyword()) {
+		before = before[:n-1]
+	}
+
+	if !insideTag(before) {
+		return []CompletionItem{}
+	}
+
+	before = significant(before)
+	if len(before) == 0 {
+		return []CompletionItem{}
+	}
+
+	switch before[len(before)-1].Kind {
+	case token.RARR:
+		return s.filterItems()
+
+	case token.PERIOD:
+		return fieldItems(doc, before[:len(before)-1])
+
+	case token.LSTMT:
+		return keywordItems()
+
+	default:
+		return variableItems(doc)
+	}
+}
+
+// insideTag reports whether the last tag delimiter of the tokens
+// opens an expression or a statement tag.
+func insideTag(tokens []token.Token) bool {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch tokens[i].Kind {
+		case token.LEXPR, token.LSTMT:
+			return true
+		case token.REXPR, token.RSTMT, token.LCOMM, token.RCOMM:
+			return false
+		}
+	}
+
+	return false
+}
+
+// significant returns the tokens without trailing whitespace.
+func significant(tokens []token.Token) []token.Token {
+	for len(tokens) != 0 && tokens[len(tokens)-1].Kind.IsOneOfMany(token.WS, token.LNBR) {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	return tokens
+}
+
+func (s *server) filterItems() []CompletionItem {
+	items := make([]CompletionItem, 0, len(s.filters))
+	for name, filter := range s.filters {
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   completionFunction,
+			Detail: filterSignature(name, filter),
+		})
+	}
+
+	return sortItems(items)
+}
+
+// fieldItems suggests fields of the object selected by the chain
+// of names, e.g. 'user.address', ending the tokens.
+func fieldItems(doc *document, tokens []token.Token) []CompletionItem {
+	var names []string
+
+	for {
+		tokens = significant(tokens)
+		if len(tokens) == 0 || tokens[len(tokens)-1].Kind != token.IDENT {
+			break
+		}
+
+		names = append(names, tokens[len(tokens)-1].Val)
+		tokens = significant(tokens[:len(tokens)-1])
+
+		if len(tokens) == 0 || tokens[len(tokens)-1].Kind != token.PERIOD {
+			break
+		}
+
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	if len(names) == 0 {
+		return []CompletionItem{}
+	}
+
+	slices.Reverse(names)
+
+	var expr parser.Expr = &parser.Ident{Name: names[0]}
+	for _, name := range names[1:] {
+		expr = &parser.SelectorExpr{X: expr, Sel: parser.Ident{Name: name}}
+	}
+
+	obj, ok := typeOf(doc.tm, expr).(types.ObjectType)
+	if !ok {
+		return []CompletionItem{}
+	}
+
+	items := make([]CompletionItem, 0, len(obj.Fields))
+	for name, typ := range obj.Fields {
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   completionField,
+			Detail: doc.tm.Resolve(typ).String(),
+		})
+	}
+
+	return sortItems(items)
+}
+
+func keywordItems() []CompletionItem {
+	items := make([]CompletionItem, len(stmtKeywords))
+	for i, kw := range stmtKeywords {
+		items[i] = CompletionItem{Label: kw.String(), Kind: completionKeyword}
+	}
+
+	return sortItems(items)
+}
+
+// variableItems suggests input variables with their inferred types
+// and names bound by let and for statements.
+func variableItems(doc *document) []CompletionItem {
+	items := make([]CompletionItem, 0, len(doc.tm))
+	seen := make(map[string]bool)
+
+	for name, typ := range doc.tm {
+		seen[name] = true
+
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   completionVariable,
+			Detail: doc.tm.Resolve(typ).String(),
+		})
+	}
+
+	local := func(ident *parser.Ident) {
+		if ident == nil || ident.Name == "" || seen[ident.Name] {
+			return
+		}
+
+		seen[ident.Name] = true
+
+		items = append(items, CompletionItem{
+			Label:  ident.Name,
+			Kind:   completionVariable,
+			Detail: "local",
+		})
+	}
+
+	parser.Inspect(doc.ast, func(e parser.Element) bool {
+		switch e := e.(type) {
+		case *parser.LetNode:
+			local(&e.Name)
+		case *parser.ForNode:
+			local(e.ForTag.Key)
+			local(&e.ForTag.Value)
+		}
+
+		return e != nil
+	})
+
+	return sortItems(items)
+}
+
+func sortItems(items []CompletionItem) []CompletionItem {
+	slices.SortFunc(items, func(a, b CompletionItem) int {
+		return cmp.Compare(a.Label, b.Label)
+	})
+
+	return items
+}
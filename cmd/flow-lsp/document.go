@@ -0,0 +1,174 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/diagnostic"
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// document is an open template with results of its analysis,
+// which are updated on each change.
+type document struct {
+	uri     string
+	version int
+	text    string
+	// Offsets of the line starts
+	lines []int
+
+	tokens []token.Token
+	// AST is partial if the template has syntax errors
+	ast   parser.Ast
+	tm    analyzer.TypeMap
+	diags []Diagnostic
+}
+
+func newDocument(uri string, version int, text string, filters renderer.Filters) *document {
+	d := &document{
+		uri:     uri,
+		version: version,
+		text:    text,
+		lines:   []int{0},
+	}
+
+	for i := range len(text) {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	src := []byte(text)
+
+	// Tokens must be lexed with the same delimiters as the parser uses
+	delims, _ := lexer.Pragma(src, lexer.DefaultDelims)
+	d.tokens = lexer.TokensFromBytesWith(src, delims)
+
+	ast, err := parser.AstFromBytes(src)
+	d.ast = ast
+	d.addDiagnostics(err)
+
+	// Types are inferred from the partial AST too, so completion and hover
+	// keep working while the template is being edited
+	a := analyzer.New()
+	a.Filters = filters
+	a.TypeMapFromAst(ast)
+	d.tm = a.Tm
+
+	if err == nil {
+		d.addDiagnostics(a.Errs.Err())
+	}
+
+	return d
+}
+
+func (d *document) addDiagnostics(err error) {
+	for _, diag := range diagnostic.FromError(d.uri, err) {
+		offset, message := len(d.text), diag.Message
+
+		switch {
+		// Positions in other templates are not in the document,
+		// so their location is a part of the message
+		case diag.File != d.uri:
+			message = diag.String()
+
+		case diag.Line != 0:
+			offset = diag.Offset
+		}
+
+		d.diags = append(d.diags, Diagnostic{
+			Range:    d.tokenRange(offset),
+			Severity: severityError,
+			Source:   "flow",
+			Message:  message,
+		})
+	}
+}
+
+// tokenRange returns the range of the token at the offset, so the whole
+// token is highlighted by diagnostics.
+func (d *document) tokenRange(offset int) Range {
+	end := offset
+
+	if tok, ok := d.tokenAt(offset); ok && tok.Pos.Offset == offset {
+		end = offset + len(tok.Val)
+	}
+
+	return Range{Start: d.position(offset), End: d.position(end)}
+}
+
+// tokenAt returns the token containing the offset.
+func (d *document) tokenAt(offset int) (token.Token, bool) {
+	i := sort.Search(len(d.tokens), func(i int) bool {
+		return d.tokens[i].Pos.Offset > offset
+	}) - 1
+
+	if i < 0 {
+		return token.Token{}, false
+	}
+
+	tok := d.tokens[i]
+	if offset >= tok.Pos.Offset+len(tok.Val) {
+		return token.Token{}, false
+	}
+
+	return tok, true
+}
+
+// position converts the byte offset to the position in UTF-16 code units.
+func (d *document) position(offset int) Position {
+	offset = min(max(offset, 0), len(d.text))
+
+	line := sort.Search(len(d.lines), func(i int) bool {
+		return d.lines[i] > offset
+	}) - 1
+
+	return Position{
+		Line:      line,
+		Character: utf16Len(d.text[d.lines[line]:offset]),
+	}
+}
+
+// offset converts the position in UTF-16 code units to the byte offset.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	lineEnd := len(d.text)
+	if pos.Line+1 < len(d.lines) {
+		lineEnd = d.lines[pos.Line+1] - 1
+	}
+
+	offset := d.lines[pos.Line]
+
+	for units := 0; offset < lineEnd && units < pos.Character; {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+
+	return offset
+}
+
+func (d *document) rangeOf(from, to int) Range {
+	return Range{Start: d.position(from), End: d.position(to)}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+
+	return n
+}
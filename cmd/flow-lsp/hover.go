@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/types"
+)
+
+// hover describes the variable, field or filter at the offset:
+// types of variables and fields are inferred by the analyzer.
+func (s *server) hover(doc *document, offset int) *Hover {
+	var (
+		text  string
+		ident *parser.Ident
+	)
+
+	// Names which are not variables
+	skip := make(map[*parser.Ident]bool)
+
+	contains := func(e parser.Element) bool {
+		return e.Pos().Offset <= offset && offset < e.End().Offset
+	}
+
+	parser.Inspect(doc.ast, func(e parser.Element) bool {
+		if e == nil || !contains(e) {
+			return false
+		}
+
+		switch e := e.(type) {
+		case *parser.FilterExpr:
+			skip[&e.Filter] = true
+
+			if contains(&e.Filter) {
+				if filter, ok := s.filters[e.Filter.Name]; ok {
+					text, ident = filterSignature(e.Filter.Name, filter), &e.Filter
+				}
+			}
+
+		case *parser.SelectorExpr:
+			skip[&e.Sel] = true
+
+			if contains(&e.Sel) {
+				if typ := typeOf(doc.tm, e); typ != nil {
+					text, ident = "(field) "+e.Sel.Name+": "+typ.String(), &e.Sel
+				}
+			}
+
+		case *parser.BlockNode:
+			skip[&e.BlockTag.Name] = true
+
+		case *parser.LetNode:
+			skip[&e.Name] = true
+
+		case *parser.ForNode:
+			skip[e.ForTag.Key] = true
+			skip[&e.ForTag.Value] = true
+
+		case *parser.Ident:
+			if skip[e] {
+				break
+			}
+
+			if typ := typeOf(doc.tm, e); typ != nil {
+				text, ident = "(variable) "+e.Name+": "+typ.String(), e
+			}
+		}
+
+		return true
+	})
+
+	if ident == nil {
+		return nil
+	}
+
+	r := doc.rangeOf(ident.Pos().Offset, ident.End().Offset)
+
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: "```flow\n" + text + "\n```",
+		},
+		Range: &r,
+	}
+}
+
+// typeOf returns the type of the input variable or its field,
+// nil if the type is unknown.
+func typeOf(tm analyzer.TypeMap, expr parser.Expr) types.Type {
+	switch e := expr.(type) {
+	case *parser.Ident:
+		typ, ok := tm[e.Name]
+		if !ok {
+			return nil
+		}
+
+		return tm.Resolve(typ)
+
+	case *parser.SelectorExpr:
+		obj, ok := typeOf(tm, e.X).(types.ObjectType)
+		if !ok {
+			return nil
+		}
+
+		typ, ok := obj.Fields[e.Sel.Name]
+		if !ok {
+			return nil
+		}
+
+		return tm.Resolve(typ)
+
+	case *parser.IndexExpr:
+		list, ok := typeOf(tm, e.X).(types.ListType)
+		if !ok {
+			return nil
+		}
+
+		return tm.Resolve(list.Elem)
+
+	default:
+		return nil
+	}
+}
+
+// filterSignature describes the filter as 'name(params): input -> output'.
+func filterSignature(name string, filter renderer.Filter) string {
+	typeName := func(t types.Type) string {
+		if t == nil {
+			return types.Any.String()
+		}
+
+		return t.String()
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "(filter) %s", name)
+
+	if len(filter.Params) != 0 {
+		params := make([]string, len(filter.Params))
+		for i, p := range filter.Params {
+			params[i] = typeName(p)
+		}
+
+		fmt.Fprintf(&b, "(%s)", strings.Join(params, ", "))
+	}
+
+	fmt.Fprintf(&b, ": %s -> %s", typeName(filter.Input), typeName(filter.Output))
+
+	return b.String()
+}
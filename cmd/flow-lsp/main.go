@@ -0,0 +1,23 @@
+// Command flow-lsp is a language server for flow templates. It communicates
+// over stdin and stdout and provides diagnostics, formatting, hover with
+// inferred types, completion of variables and filters and semantic tokens.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	s := newServer(os.Stdin, os.Stdout)
+
+	if err := s.serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "flow-lsp: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The protocol requires exit code 1 if the client exits without shutdown
+	if !s.shutdown {
+		os.Exit(1)
+	}
+}
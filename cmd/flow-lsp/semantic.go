@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Indexes of the types in semanticTokenTypes
+const (
+	semanticKeyword = iota
+	semanticVariable
+	semanticFunction
+	semanticProperty
+	semanticNumber
+	semanticString
+	semanticComment
+	semanticOperator
+	semanticMacro
+)
+
+// semanticTokenTypes is the legend of the token types, tag delimiters
+// are highlighted as macros.
+var semanticTokenTypes = []string{
+	semanticKeyword:  "keyword",
+	semanticVariable: "variable",
+	semanticFunction: "function",
+	semanticProperty: "property",
+	semanticNumber:   "number",
+	semanticString:   "string",
+	semanticComment:  "comment",
+	semanticOperator: "operator",
+	semanticMacro:    "macro",
+}
+
+// semanticType returns the type of the token in the legend, false if
+// the token is not highlighted. prev is the previous significant token.
+func semanticType(tok, prev token.Token) (int, bool) {
+	switch k := tok.Kind; {
+	case k == token.IDENT:
+		switch prev.Kind {
+		case token.RARR:
+			return semanticFunction, true
+		case token.PERIOD:
+			return semanticProperty, true
+		default:
+			return semanticVariable, true
+		}
+
+	case k == token.INT || k == token.FLOAT:
+		return semanticNumber, true
+
+	case k == token.STR || k == token.NOT_TERMINATED_STR:
+		return semanticString, true
+
+	case k.IsOneOfMany(token.COMM_TEXT, token.LCOMM, token.RCOMM):
+		return semanticComment, true
+
+	case k.IsOneOfMany(token.LEXPR, token.REXPR, token.LSTMT, token.RSTMT):
+		return semanticMacro, true
+
+	case k.IsKeyword():
+		return semanticKeyword, true
+
+	case k.IsOperator():
+		return semanticOperator, true
+
+	default:
+		return 0, false
+	}
+}
+
+// semanticTokens encodes the highlighted tokens of the document relative
+// to each other, as the protocol requires. Tokens spanning several lines
+// are split by lines.
+func semanticTokens(doc *document) SemanticTokens {
+	data := []int{}
+
+	var (
+		prev     token.Token
+		lastLine int
+		lastChar int
+	)
+
+	for _, tok := range doc.tokens {
+		typ, ok := semanticType(tok, prev)
+
+		if !tok.Kind.IsOneOfMany(token.WS, token.LNBR, token.TEXT) {
+			prev = tok
+		}
+
+		if !ok {
+			continue
+		}
+
+		offset := tok.Pos.Offset
+
+		for _, line := range strings.SplitAfter(tok.Val, "\n") {
+			text := strings.TrimSuffix(line, "\n")
+			pos := doc.position(offset)
+			offset += len(line)
+
+			if text == "" {
+				continue
+			}
+
+			deltaChar := pos.Character
+			if pos.Line == lastLine {
+				deltaChar -= lastChar
+			}
+
+			data = append(data, pos.Line-lastLine, deltaChar, utf16Len(text), typ, 0)
+			lastLine, lastChar = pos.Line, pos.Character
+		}
+	}
+
+	return SemanticTokens{Data: data}
+}
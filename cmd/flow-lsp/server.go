@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/flowtemplates/flow-go/formatter"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+// errExit stops the server on the 'exit' notification.
+var errExit = errors.New("exit")
+
+type server struct {
+	conn    *conn
+	docs    map[string]*document
+	filters renderer.Filters
+	// Set by the 'shutdown' request, the exit code is 0 only after it
+	shutdown bool
+}
+
+func newServer(r io.Reader, w io.Writer) *server {
+	return &server{
+		conn:    newConn(r, w),
+		docs:    make(map[string]*document),
+		filters: renderer.DefaultFilters(),
+	}
+}
+
+// serve handles messages until the 'exit' notification or the end of input.
+func (s *server) serve() error {
+	for {
+		req, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var respErr *responseError
+		if errors.As(err, &respErr) {
+			if err := s.conn.reply(req.ID, nil, err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		result, err := s.handleSafe(req)
+		if errors.Is(err, errExit) {
+			return nil
+		}
+
+		// Errors of notifications can't be replied, only failed writes stop the server
+		if req.isNotification() {
+			if err != nil && !errors.As(err, &respErr) {
+				return err
+			}
+
+			continue
+		}
+
+		if err := s.conn.reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// handleSafe is handle which answers with the internal error instead of
+// stopping the server if the handler panics.
+func (s *server) handleSafe(req *request) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = &responseError{
+				Code:    codeInternalError,
+				Message: fmt.Sprintf("internal error: %v", r),
+			}
+		}
+	}()
+
+	return s.handle(req)
+}
+
+// handle dispatches the message to its handler. Results of notifications
+// are discarded.
+func (s *server) handle(req *request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(), nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+
+		return nil, nil
+
+	case "exit":
+		return nil, errExit
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		doc := params.TextDocument
+
+		return nil, s.update(doc.URI, doc.Version, doc.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Version, text)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		delete(s.docs, params.TextDocument.URI)
+
+		// Diagnostics of closed documents are cleared
+		return nil, s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return formatting(doc), nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.hover(doc, doc.offset(params.Position)), nil
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.completion(doc, doc.offset(params.Position)), nil
+
+	case "textDocument/semanticTokens/full":
+		var params SemanticTokensParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return semanticTokens(doc), nil
+
+	default:
+		return nil, &responseError{
+			Code:    codeMethodNotFound,
+			Message: "method not found: " + req.Method,
+		}
+	}
+}
+
+func unmarshalParams(req *request, params any) error {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *server) initialize() InitializeResult {
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           syncFull,
+			DocumentFormattingProvider: true,
+			HoverProvider:              true,
+			CompletionProvider: CompletionOptions{
+				TriggerCharacters: []string{".", ">", " "},
+			},
+			SemanticTokensProvider: SemanticTokensOptions{
+				Legend: SemanticTokensLegend{
+					TokenTypes:     semanticTokenTypes,
+					TokenModifiers: []string{},
+				},
+				Full: true,
+			},
+		},
+		ServerInfo: ServerInfo{Name: "flow-lsp"},
+	}
+}
+
+// update analyzes the new text of the document and publishes its diagnostics.
+func (s *server) update(uri string, version int, text string) error {
+	doc := newDocument(uri, version, text, s.filters)
+	s.docs[uri] = doc
+
+	diags := doc.diags
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     version,
+		Diagnostics: diags,
+	})
+}
+
+func (s *server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{
+			Code:    codeInvalidParams,
+			Message: fmt.Sprintf("document %s is not open", uri),
+		}
+	}
+
+	return doc, nil
+}
+
+// formatting returns the edit replacing the whole document with its
+// formatted text, or no edits if the document has syntax errors.
+func formatting(doc *document) []TextEdit {
+	out, err := formatter.Bytes([]byte(doc.text))
+	if err != nil || string(out) == doc.text {
+		return []TextEdit{}
+	}
+
+	return []TextEdit{{
+		Range:   doc.rangeOf(0, len(doc.text)),
+		NewText: string(out),
+	}}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+const uri = "file:///page.flow"
+
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// exchange sends the messages to the server and returns its responses
+// and notifications. Requests get IDs by their indexes, 'method!' denotes
+// a notification.
+func exchange(t *testing.T, msgs ...[2]any) []message {
+	t.Helper()
+
+	var in bytes.Buffer
+
+	for i, msg := range msgs {
+		method := msg[0].(string) //nolint: forcetypeassert
+
+		m := map[string]any{"jsonrpc": "2.0", "method": method, "params": msg[1]}
+		if method[len(method)-1] == '!' {
+			m["method"] = method[:len(method)-1]
+		} else {
+			m["id"] = i
+		}
+
+		body, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	var out bytes.Buffer
+	if err := newServer(&in, &out).serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	var res []message
+
+	r := textproto.NewReader(bufio.NewReader(&out))
+
+	for {
+		header, err := r.ReadMIMEHeader()
+		if len(header) == 0 {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r.R, body); err != nil {
+			t.Fatal(err)
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+
+		res = append(res, msg)
+	}
+
+	return res
+}
+
+func open(text string) [2]any {
+	return [2]any{"textDocument/didOpen!", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "version": 1, "text": text},
+	}}
+}
+
+func at(method string, line, char int) [2]any {
+	return [2]any{method, map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": char},
+	}}
+}
+
+func decode[T any](t *testing.T, data json.RawMessage) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestInitialize(t *testing.T) {
+	res := exchange(t,
+		[2]any{"initialize", map[string]any{}},
+		[2]any{"shutdown", nil},
+		[2]any{"exit!", nil},
+	)
+
+	if len(res) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(res))
+	}
+
+	result := decode[InitializeResult](t, res[0].Result)
+	if !result.Capabilities.HoverProvider || result.Capabilities.TextDocumentSync != syncFull {
+		t.Errorf("unexpected capabilities: %+v", result.Capabilities)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []Diagnostic
+	}{
+		{
+			name:     "Valid template",
+			text:     "Hello {{ name }}",
+			expected: []Diagnostic{},
+		},
+		{
+			name: "Syntax error",
+			text: "Hello\n{{ name ",
+			expected: []Diagnostic{{
+				Range:    Range{Start: Position{Line: 1, Character: 8}, End: Position{Line: 1, Character: 8}},
+				Severity: severityError,
+				Source:   "flow",
+				Message:  "'}}' expected",
+			}},
+		},
+		{
+			name: "Number out of range",
+			text: "{{ 1" + strings.Repeat("0", 400) + " }}",
+			expected: []Diagnostic{{
+				Range:    Range{Start: Position{Line: 0, Character: 3}, End: Position{Line: 0, Character: 404}},
+				Severity: severityError,
+				Source:   "flow",
+				Message:  "invalid number literal",
+			}},
+		},
+		{
+			name: "Type error",
+			text: "{{ n -> upper }}{{ n + 1 }}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := exchange(t, open(tc.text))
+
+			if len(res) != 1 || res[0].Method != "textDocument/publishDiagnostics" {
+				t.Fatalf("expected diagnostics, got %+v", res)
+			}
+
+			params := decode[PublishDiagnosticsParams](t, res[0].Params)
+
+			if tc.expected == nil {
+				if len(params.Diagnostics) == 0 {
+					t.Error("expected diagnostics, got none")
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(params.Diagnostics, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, params.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsOfOtherTemplate(t *testing.T) {
+	d := newDocument(uri, 1, "{{ a }}", renderer.DefaultFilters())
+	d.addDiagnostics(renderer.TemplateError{
+		Name:   "base.flow",
+		Source: []byte("{{ q }}"),
+		Err:    renderer.Error{Pos: token.Position{Line: 1, Column: 4, Offset: 3}, Err: errors.New("q not declared")},
+	})
+
+	expected := []Diagnostic{{
+		Range:    Range{Start: Position{Line: 0, Character: 7}, End: Position{Line: 0, Character: 7}},
+		Severity: severityError,
+		Source:   "flow",
+		Message:  "base.flow:1:4: q not declared",
+	}}
+
+	if !reflect.DeepEqual(d.diags, expected) {
+		t.Errorf("expected %+v, got %+v", expected, d.diags)
+	}
+}
+
+func TestHover(t *testing.T) {
+	text := "{{ user.name -> upper }}\n{{ user.age + 1 }}"
+
+	testCases := []struct {
+		name     string
+		line     int
+		char     int
+		expected string
+	}{
+		{
+			name:     "Variable",
+			line:     0,
+			char:     4,
+			expected: "(variable) user: {age: any, name: string}",
+		},
+		{
+			name:     "Field",
+			line:     0,
+			char:     9,
+			expected: "(field) name: string",
+		},
+		{
+			name:     "Filter",
+			line:     0,
+			char:     17,
+			expected: "(filter) upper: string -> string",
+		},
+		{
+			name: "Text",
+			line: 0,
+			char: 23,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := exchange(t, open(text), at("textDocument/hover", tc.line, tc.char))
+
+			hover := decode[*Hover](t, res[1].Result)
+
+			if tc.expected == "" {
+				if hover != nil {
+					t.Errorf("expected no hover, got %+v", hover)
+				}
+
+				return
+			}
+
+			if hover == nil {
+				t.Fatal("expected hover, got none")
+			}
+
+			expected := "```flow\n" + tc.expected + "\n```"
+			if hover.Contents.Value != expected {
+				t.Errorf("expected %q, got %q", expected, hover.Contents.Value)
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		char     int
+		expected []string
+	}{
+		{
+			name:     "Variables",
+			text:     "{% let total = count + 1 %}{{ name -> upper }}{{  }}",
+			char:     49,
+			expected: []string{"count", "name", "total"},
+		},
+		{
+			name:     "Fields",
+			text:     "{{ user. }}{{ user.name }}{{ user.age + 1 }}",
+			char:     8,
+			expected: []string{"age", "name"},
+		},
+		{
+			name: "Filters",
+			text: "{{ name -> up }}",
+			char: 13,
+			expected: []string{
+				"camel", "capitalize", "join", "kebab", "length", "lower", "pascal",
+				"replace", "snake", "title", "trim", "truncate", "upper",
+			},
+		},
+		{
+			name:     "Keywords",
+			text:     "{% ",
+			char:     3,
+			expected: []string{"block", "case", "default", "else", "end", "extend", "for", "genif", "if", "let", "raw", "switch"},
+		},
+		{
+			name:     "Text",
+			text:     "Hello {{ name }} wo",
+			char:     19,
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := exchange(t, open(tc.text), at("textDocument/completion", 0, tc.char))
+
+			labels := []string{}
+			for _, item := range decode[[]CompletionItem](t, res[1].Result) {
+				labels = append(labels, item.Label)
+			}
+
+			if !reflect.DeepEqual(labels, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, labels)
+			}
+		})
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	res := exchange(t,
+		open("{{name}}"),
+		[2]any{"textDocument/formatting", map[string]any{"textDocument": map[string]any{"uri": uri}}},
+	)
+
+	expected := []TextEdit{{
+		Range:   Range{End: Position{Character: 8}},
+		NewText: "{{ name }}",
+	}}
+
+	if edits := decode[[]TextEdit](t, res[1].Result); !reflect.DeepEqual(edits, expected) {
+		t.Errorf("expected %+v, got %+v", expected, edits)
+	}
+}
+
+func TestSemanticTokens(t *testing.T) {
+	res := exchange(t,
+		open("é {{ a.b -> upper }}\n{# x #}"),
+		[2]any{"textDocument/semanticTokens/full", map[string]any{"textDocument": map[string]any{"uri": uri}}},
+	)
+
+	expected := []int{
+		0, 2, 2, semanticMacro, 0, // {{
+		0, 3, 1, semanticVariable, 0, // a
+		0, 1, 1, semanticOperator, 0, // .
+		0, 1, 1, semanticProperty, 0, // b
+		0, 2, 2, semanticOperator, 0, // ->
+		0, 3, 5, semanticFunction, 0, // upper
+		0, 6, 2, semanticMacro, 0, // }}
+		1, 0, 2, semanticComment, 0, // {#
+		0, 2, 3, semanticComment, 0, // ' x '
+		0, 3, 2, semanticComment, 0, // #}
+	}
+
+	if tokens := decode[SemanticTokens](t, res[1].Result); !reflect.DeepEqual(tokens.Data, expected) {
+		t.Errorf("expected %v, got %v", expected, tokens.Data)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	res := exchange(t, [2]any{"workspace/symbol", map[string]any{}})
+
+	if len(res) != 1 || res[0].Error == nil || res[0].Error.Code != codeMethodNotFound {
+		t.Errorf("expected method not found error, got %+v", res)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	s := newServer(&bytes.Buffer{}, io.Discard)
+	s.docs = nil // Storing the opened document panics
+
+	params, err := json.Marshal(map[string]any{
+		"textDocument": map[string]any{"uri": uri, "version": 1, "text": "{{ a }}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.handleSafe(&request{Method: "textDocument/didOpen", Params: params})
+
+	var respErr *responseError
+	if !errors.As(err, &respErr) || respErr.Code != codeInternalError {
+		t.Errorf("expected internal error, got %v", err)
+	}
+}
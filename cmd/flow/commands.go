@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/diagnostic"
+	"github.com/flowtemplates/flow-go/formatter"
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func (c *cli) render(args []string) error {
+	fs := c.newFlagSet("render")
+
+	var files, sets, setStrings stringsFlag
+
+	fs.Var(&files, "f", "read variables from JSON or YAML `file`, may be repeated")
+	fs.Var(&sets, "set", "set variable as `key=value`, numbers and booleans are parsed, may be repeated")
+	fs.Var(&setStrings, "set-string", "set variable as `key=value`, the value is always a string, may be repeated")
+	output := fs.String("o", "", "write the output to `file` instead of stdout")
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 1 {
+		return usageError{msg: "only one template can be rendered"}
+	}
+
+	input, err := loadValues(files, sets, setStrings)
+	if err != nil {
+		return err
+	}
+
+	file, src, err := c.readTemplate(templateArgs(args)[0])
+	if err != nil {
+		return err
+	}
+
+	// Templates are extended by paths relative to the template
+	dir := "."
+	if len(args) == 1 && args[0] != "-" {
+		dir = filepath.Dir(args[0])
+	}
+
+	env := &renderer.Environment{
+		Loader: renderer.FSLoader{FS: os.DirFS(dir)},
+	}
+
+	out, err := env.RenderBytes(src, input)
+	if errors.Is(err, renderer.ErrSkipFile) {
+		fmt.Fprintf(c.stderr, "%s: skipped by genif\n", file)
+
+		return nil
+	}
+
+	if err != nil {
+		// Extended templates are named relative to the directory
+		diags := diagnostic.FromError(file, err)
+		for i := range diags {
+			if diags[i].Source != nil {
+				diags[i].File = filepath.Join(dir, diags[i].File)
+			}
+		}
+
+		return c.reportDiagnostics(src, diags)
+	}
+
+	if *output != "" {
+		return os.WriteFile(*output, out, 0o644) //nolint: gosec
+	}
+
+	_, err = c.stdout.Write(out)
+
+	return err
+}
+
+func (c *cli) fmt(args []string) error {
+	fs := c.newFlagSet("fmt")
+	write := fs.Bool("w", false, "write the result to the template file instead of stdout")
+	list := fs.Bool("l", false, "list templates which formatting differs")
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+
+	for _, name := range templateArgs(args) {
+		if *write && name == "-" {
+			return usageError{msg: "can't write the result to stdin"}
+		}
+
+		file, src, err := c.readTemplate(name)
+		if err != nil {
+			return err
+		}
+
+		out, err := formatter.Bytes(src)
+		if err != nil {
+			_ = c.report(file, src, err)
+			failed = true
+
+			continue
+		}
+
+		changed := !bytes.Equal(src, out)
+
+		if *list && changed {
+			fmt.Fprintln(c.stdout, file)
+		}
+
+		if *write && changed {
+			info, err := os.Stat(name)
+			if err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(name, out, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+
+		if !*list && !*write {
+			if _, err := c.stdout.Write(out); err != nil {
+				return err
+			}
+		}
+	}
+
+	if failed {
+		return errFailed
+	}
+
+	return nil
+}
+
+func (c *cli) check(args []string) error {
+	fs := c.newFlagSet("check")
+	jsonOutput := fs.Bool("json", false, "print diagnostics to stdout as JSON")
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var all []diagnostic.Diagnostic
+
+	for _, name := range templateArgs(args) {
+		file, src, err := c.readTemplate(name)
+		if err != nil {
+			return err
+		}
+
+		_, err = analyze(src)
+
+		diags := diagnostic.FromError(file, err)
+		all = append(all, diags...)
+
+		if !*jsonOutput {
+			if err := (diagnostic.Printer{}).Fprint(c.stderr, src, diags); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *jsonOutput {
+		if err := diagnostic.WriteJSON(c.stdout, all); err != nil {
+			return err
+		}
+	}
+
+	if len(all) != 0 {
+		return errFailed
+	}
+
+	return nil
+}
+
+func (c *cli) vars(args []string) error {
+	fs := c.newFlagSet("vars")
+	jsonOutput := fs.Bool("json", false, "print variables as JSON object")
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 1 {
+		return usageError{msg: "only one template can be analyzed"}
+	}
+
+	file, src, err := c.readTemplate(templateArgs(args)[0])
+	if err != nil {
+		return err
+	}
+
+	tm, err := analyze(src)
+	if err != nil {
+		return c.report(file, src, err)
+	}
+
+	vars := make(map[string]string, len(tm))
+	for name, typ := range tm {
+		vars[name] = tm.Resolve(typ).String()
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(vars)
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Fprintf(c.stdout, "%s: %s\n", name, vars[name])
+	}
+
+	return nil
+}
+
+// analyze parses the template and infers types of its variables.
+// The error is a parser.ErrorList or analyzer.TypeErrors.
+func analyze(src []byte) (analyzer.TypeMap, error) {
+	ast, err := parser.AstFromBytes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	a := analyzer.New()
+	a.TypeMapFromAst(ast)
+
+	return a.Tm, a.Errs.Err()
+}
+
+// report prints diagnostics of the error found in the template
+// and returns errFailed.
+func (c *cli) report(file string, src []byte, err error) error {
+	return c.reportDiagnostics(src, diagnostic.FromError(file, err))
+}
+
+func (c *cli) reportDiagnostics(src []byte, diags []diagnostic.Diagnostic) error {
+	if err := (diagnostic.Printer{}).Fprint(c.stderr, src, diags); err != nil {
+		return err
+	}
+
+	return errFailed
+}
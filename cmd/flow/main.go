@@ -0,0 +1,204 @@
+// Command flow renders, formats and checks flow templates.
+//
+// Usage:
+//
+//	flow render [-f values.yaml] [--set key=value] [--set-string key=value] [-o output] [template]
+//	flow fmt [-w] [-l] [templates...]
+//	flow check [--json] [templates...]
+//	flow vars [--json] [template]
+//
+// Templates are read from stdin if no files are given or the file is "-".
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `flow renders, formats and checks flow templates.
+
+Usage:
+
+	flow <command> [flags] [arguments]
+
+Commands:
+
+	render  render the template with variables from files and flags
+	fmt     format templates
+	check   report syntax and type errors of templates
+	vars    print variables used by the template with their types
+
+Run 'flow <command> -h' for flags of the command.
+`
+
+// errFailed is returned by commands that have already reported
+// their errors, so only the exit code is left to set.
+var errFailed = errors.New("failed")
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	name string
+	run  func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{"render", (*cli).render},
+	{"fmt", (*cli).fmt},
+	{"check", (*cli).check},
+	{"vars", (*cli).vars},
+}
+
+var usages = map[string]string{
+	"render": "flow render [-f values.yaml] [--set key=value] [--set-string key=value] [-o output] [template]",
+	"fmt":    "flow fmt [-w] [-l] [templates...]",
+	"check":  "flow check [--json] [templates...]",
+	"vars":   "flow vars [--json] [template]",
+}
+
+func main() {
+	c := &cli{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// run executes the command given by args and returns the exit code.
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(c.stderr, usage)
+
+		return exitUsage
+	}
+
+	if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(c.stdout, usage)
+
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(c, args[1:])
+
+		switch {
+		case err == nil:
+			return exitOK
+
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+
+		case errors.Is(err, errUsage):
+			return exitUsage
+
+		case errors.As(err, new(usageError)):
+			fmt.Fprintf(c.stderr, "%s\nusage: %s\n", err, usages[cmd.name])
+
+			return exitUsage
+
+		case errors.Is(err, errFailed):
+			return exitError
+
+		default:
+			fmt.Fprintf(c.stderr, "flow %s: %s\n", cmd.name, err)
+
+			return exitError
+		}
+	}
+
+	fmt.Fprintf(c.stderr, "flow: unknown command %q\nRun 'flow help' for usage.\n", args[0])
+
+	return exitUsage
+}
+
+// usageError is returned for invalid flags and arguments.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// errUsage is returned for invalid flags, which the flag set has reported.
+var errUsage = errors.New("invalid flags")
+
+// newFlagSet returns flag set of the command, which errors are returned
+// instead of making the program exit.
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("flow "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s\n", usages[name])
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseArgs parses flags which may be mixed with positional arguments,
+// e.g. 'flow render page.flow --set a=1', and returns the arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+
+			return nil, errUsage
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// readTemplate reads the template from the file, or from stdin if the name
+// is "-". It returns the name to report errors with.
+func (c *cli) readTemplate(name string) (string, []byte, error) {
+	if name == "-" {
+		src, err := io.ReadAll(c.stdin)
+
+		return "<stdin>", src, err
+	}
+
+	src, err := os.ReadFile(name)
+
+	return name, src, err
+}
+
+// templateArgs returns names of templates given by args, stdin if there are none.
+func templateArgs(args []string) []string {
+	if len(args) == 0 {
+		return []string{"-"}
+	}
+
+	return args
+}
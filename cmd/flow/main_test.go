@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	c := &cli{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+	}
+
+	return c.run(args), stdout.String(), stderr.String()
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.flow", "[{% block body %}{% end %}]")
+	tmpl := writeFile(t, dir, "page.flow",
+		"{% extend \"base.flow\" %}\n{% block body %}{{ name }} {{ image.repo }}:{{ image.tag }} {{ replicas + 1 }}{% end %}")
+	values := writeFile(t, dir, "values.yaml", "name: flow\nimage:\n  repo: app\n  tag: latest\n")
+	overrides := writeFile(t, dir, "values.json", `{"name": "json"}`)
+
+	testCases := []struct {
+		name     string
+		stdin    string
+		args     []string
+		code     int
+		expected string
+	}{
+		{
+			name:     "Values from files and flags",
+			args:     []string{"render", tmpl, "-f", values, "-f", overrides, "--set", "replicas=2", "--set-string", "image.tag=1.0"},
+			expected: "[json app:1.0 3]",
+		},
+		{
+			name:     "Template from stdin",
+			stdin:    "Hello {{ name -> upper }}",
+			args:     []string{"render", "--set", "name=flow"},
+			expected: "Hello FLOW",
+		},
+		{
+			name:  "Skipped by genif",
+			stdin: "{% genif enabled %}x",
+			args:  []string{"render", "--set", "enabled=false"},
+		},
+		{
+			name:     "Leading zeros and hex in --set",
+			stdin:    "{{ zip }} {{ id }}",
+			args:     []string{"render", "--set", "zip=00123", "--set", "id=0x1F"},
+			expected: "00123 0x1F",
+		},
+		{
+			name:  "Syntax error",
+			stdin: "{{ a b }}",
+			args:  []string{"render"},
+			code:  exitError,
+		},
+		{
+			name: "Invalid --set",
+			args: []string{"render", "--set", "name"},
+			code: exitUsage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tc.stdin, tc.args...)
+			if code != tc.code {
+				t.Fatalf("Args: %q\nExpected exit code %d, got %d\nStderr: %s", tc.args, tc.code, code, stderr)
+			}
+
+			if stdout != tc.expected {
+				t.Errorf("Args: %q\nMismatch.\nExpected:\n%q\nGot:\n%q", tc.args, tc.expected, stdout)
+			}
+		})
+	}
+}
+
+func TestRenderErrorInExtendedTemplate(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.flow", "[\n  {{ q }}{% block body %}{% end %}]")
+	tmpl := writeFile(t, dir, "page.flow", "{% extend \"base.flow\" %}")
+
+	code, _, stderr := runCLI(t, "", "render", tmpl)
+
+	expected := base + ":2:6: q not declared\n  {{ q }}{% block body %}{% end %}]\n     ^\n"
+	if code != exitError || stderr != expected {
+		t.Errorf("Unexpected result: %d\nExpected:\n%s\nGot:\n%s", code, expected, stderr)
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected any
+	}{
+		{"2", 2},
+		{"-2", -2},
+		{"0", 0},
+		{"1.5", 1.5},
+		{"0.5", 0.5},
+		{"true", true},
+		{"False", false},
+		{"00123", "00123"},
+		{"-007", "-007"},
+		{"0x1F", "0x1F"},
+		{"0o17", "0o17"},
+		{"1_000", "1_000"},
+		{"inf", "inf"},
+		{"NaN", "NaN"},
+		{"t", "t"},
+		{"yes", "yes"},
+		{"null", "null"},
+		{"2025-01-01", "2025-01-01"},
+	}
+
+	for _, tc := range testCases {
+		if got := parseScalar(tc.raw); got != tc.expected {
+			t.Errorf("parseScalar(%q) = %#v, expected %#v", tc.raw, got, tc.expected)
+		}
+	}
+}
+
+func TestRenderOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.txt")
+
+	if code, _, stderr := runCLI(t, "{{ a }}", "render", "-o", output, "--set", "a=1"); code != exitOK {
+		t.Fatalf("Unexpected exit code %d: %s", code, stderr)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "1" {
+		t.Errorf("Expected %q, got %q", "1", got)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	dir := t.TempDir()
+	formatted := writeFile(t, dir, "formatted.flow", "{{ a }}\n")
+	unformatted := writeFile(t, dir, "unformatted.flow", "{{a}}\n")
+
+	code, stdout, _ := runCLI(t, "{{a->upper}}", "fmt")
+	if code != exitOK || stdout != "{{ a -> upper }}" {
+		t.Errorf("Unexpected result of formatting stdin: %d, %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, "", "fmt", "-l", formatted, unformatted)
+	if code != exitOK || stdout != unformatted+"\n" {
+		t.Errorf("Unexpected list of unformatted files: %d, %q", code, stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "", "fmt", "-w", unformatted); code != exitOK {
+		t.Fatalf("Unexpected exit code %d: %s", code, stderr)
+	}
+
+	if got, _ := os.ReadFile(unformatted); string(got) != "{{ a }}\n" {
+		t.Errorf("File is not formatted: %q", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "{{ a }}{{ a -> nope }}", "check")
+	if code != exitError || stdout != "" || !strings.Contains(stderr, "<stdin>:1:") {
+		t.Errorf("Unexpected result: %d, %q, %q", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runCLI(t, "{{ a b }}", "check", "--json")
+	if code != exitError || !strings.Contains(stdout, `"file": "<stdin>"`) {
+		t.Errorf("Unexpected JSON result: %d, %q", code, stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "{% if a %}{{ b }}{% end %}", "check"); code != exitOK {
+		t.Errorf("Unexpected exit code %d: %s", code, stderr)
+	}
+}
+
+func TestVars(t *testing.T) {
+	src := "{% for i in items %}{{ i.name -> upper }}{% end %}{% if enabled %}{{ count + 1 }}{% end %}"
+
+	code, stdout, stderr := runCLI(t, src, "vars")
+	if code != exitOK {
+		t.Fatalf("Unexpected exit code %d: %s", code, stderr)
+	}
+
+	expected := "count: number\nenabled: boolean\nitems: []{name: string}\n"
+	if stdout != expected {
+		t.Errorf("Mismatch.\nExpected:\n%q\nGot:\n%q", expected, stdout)
+	}
+
+	_, stdout, _ = runCLI(t, "{{ name }}", "vars", "--json")
+	if stdout != "{\n  \"name\": \"string\"\n}\n" {
+		t.Errorf("Unexpected JSON output: %q", stdout)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	testCases := [][]string{
+		{},
+		{"nope"},
+		{"render", "--nope"},
+		{"vars", "a.flow", "b.flow"},
+	}
+
+	for _, args := range testCases {
+		if code, _, _ := runCLI(t, "", args...); code != exitUsage {
+			t.Errorf("Args: %q\nExpected exit code %d, got %d", args, exitUsage, code)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flowtemplates/flow-go/renderer"
+	"gopkg.in/yaml.v3"
+)
+
+// stringsFlag is a flag which may be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+
+	return nil
+}
+
+// loadValues merges variables from the files, then '--set' and '--set-string'
+// flags, later values override earlier ones.
+func loadValues(files, sets, setStrings []string) (renderer.Input, error) {
+	input := renderer.Input{}
+
+	for _, name := range files {
+		values, err := readValuesFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		mergeValues(input, values)
+	}
+
+	for _, set := range sets {
+		if err := setValue(input, set, true); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, set := range setStrings {
+		if err := setValue(input, set, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return input, nil
+}
+
+// readValuesFile reads variables from a JSON or YAML file,
+// the format is chosen by extension.
+func readValuesFile(name string) (map[string]any, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+
+	switch ext := filepath.Ext(name); ext {
+	case ".json":
+		err = json.Unmarshal(src, &values)
+
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(src, &values)
+
+	default:
+		return nil, fmt.Errorf("%s: unknown format of values %q, expected .json, .yaml or .yml", name, ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return values, nil
+}
+
+// mergeValues copies values into dst, maps present in both are merged.
+func mergeValues(dst, values map[string]any) {
+	for key, v := range values {
+		src, ok := v.(map[string]any)
+		if !ok {
+			dst[key] = v
+
+			continue
+		}
+
+		if existing, ok := dst[key].(map[string]any); ok {
+			mergeValues(existing, src)
+		} else {
+			dst[key] = src
+		}
+	}
+}
+
+// setValue sets the variable from 'key=value' flag. The key may be a path
+// to the field of a map: 'image.tag=1.0'. If parse is set, the value is parsed
+// by parseScalar: a decimal integer, then a float, then a boolean, anything
+// else stays a string: 'replicas=3'.
+func setValue(input map[string]any, set string, parse bool) error {
+	path, raw, ok := strings.Cut(set, "=")
+	if !ok || path == "" {
+		return usageError{msg: fmt.Sprintf("invalid value %q, expected key=value", set)}
+	}
+
+	var v any = raw
+	if parse {
+		v = parseScalar(raw)
+	}
+
+	keys := strings.Split(path, ".")
+	m := input
+
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[key] = next
+		}
+
+		m = next
+	}
+
+	m[keys[len(keys)-1]] = v
+
+	return nil
+}
+
+// parseScalar parses the value of '--set' flag. Only decimal numbers and
+// booleans get their types, so values like '00123', '0x1F' or '2025-01-01'
+// stay strings.
+func parseScalar(raw string) any {
+	// Leading zeros are significant in zip codes, IDs and versions,
+	// underscores are accepted by strconv as digit separators
+	digits := strings.TrimLeft(raw, "+-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' || strings.Contains(raw, "_") {
+		return raw
+	}
+
+	if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return int(i)
+	}
+
+	if f, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+
+	// Single letters like 't' are not booleans
+	if b, err := strconv.ParseBool(raw); err == nil && len(raw) > 1 {
+		return b
+	}
+
+	return raw
+}
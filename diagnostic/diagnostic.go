@@ -0,0 +1,204 @@
+// Package diagnostic formats errors of parsing, analyzing and rendering
+// of templates for humans, with source snippets, and for tools, as JSON.
+package diagnostic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Positioned is implemented by errors pointing to the place in the template,
+// e.g. parser.Error and analyzer.TypeError.
+type Positioned interface {
+	error
+	Position() token.Position
+}
+
+// Sourced is implemented by errors found in another template than the one
+// they are reported for, e.g. renderer.TemplateError of the extended template.
+type Sourced interface {
+	error
+	Template() (name string, src []byte)
+}
+
+// Diagnostic is a single problem found in the template.
+// Line is zero if the problem has no position. Column is counted
+// in characters, UTF16Column in UTF-16 code units for editors.
+type Diagnostic struct {
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Column      int    `json:"column,omitempty"`
+	UTF16Column int    `json:"utf16Column,omitempty"`
+	Offset      int    `json:"offset"`
+	Message     string `json:"message"`
+	// Source of the file if it is not the one diagnostics are found in,
+	// see Sourced
+	Source []byte `json:"-"`
+}
+
+func (d Diagnostic) String() string {
+	return d.location() + d.Message
+}
+
+// location returns "file:line:col: " prefix of the message.
+func (d Diagnostic) location() string {
+	var b strings.Builder
+
+	if d.File != "" {
+		b.WriteString(d.File)
+		b.WriteString(":")
+	}
+
+	if d.Line != 0 {
+		fmt.Fprintf(&b, "%d:%d:", d.Line, d.Column)
+	}
+
+	if b.Len() != 0 {
+		b.WriteString(" ")
+	}
+
+	return b.String()
+}
+
+// FromError returns diagnostics of err found in the file, one for each error
+// of lists like parser.ErrorList. Wrapped errors are unwrapped to get their
+// positions, errors of other templates are reported against them.
+func FromError(file string, err error) []Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if t, ok := e.(Sourced); ok {
+			name, src := t.Template()
+
+			diags := FromError(name, errors.Unwrap(e))
+			for i := range diags {
+				diags[i].Source = src
+			}
+
+			return diags
+		}
+
+		if p, ok := e.(Positioned); ok {
+			pos := p.Position()
+
+			return []Diagnostic{{
+				File:        file,
+				Line:        pos.Line,
+				Column:      pos.Column,
+				UTF16Column: pos.UTF16Column,
+				Offset:      pos.Offset,
+				Message:     p.Error(),
+			}}
+		}
+
+		if list, ok := e.(interface{ Unwrap() []error }); ok {
+			var diags []Diagnostic
+			for _, e := range list.Unwrap() {
+				diags = append(diags, FromError(file, e)...)
+			}
+
+			return diags
+		}
+	}
+
+	return []Diagnostic{{
+		File:    file,
+		Message: err.Error(),
+	}}
+}
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[1;31m"
+)
+
+// Printer prints diagnostics with lines of the source they point to:
+//
+//	main.flow:1:6: '}}' expected
+//	{{ a b }}
+//	     ^
+type Printer struct {
+	// Color enables ANSI escape sequences in the output
+	Color bool
+}
+
+// Fprint writes diagnostics found in src to w. Diagnostics of other
+// templates are printed with lines of their Source.
+func (p Printer) Fprint(w io.Writer, src []byte, diags []Diagnostic) error {
+	for _, d := range diags {
+		if _, err := io.WriteString(w, p.format(src, d)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p Printer) format(src []byte, d Diagnostic) string {
+	var b strings.Builder
+
+	b.WriteString(p.paint(colorBold, d.location()))
+	b.WriteString(d.Message)
+	b.WriteString("\n")
+
+	if d.Source != nil {
+		src = d.Source
+	}
+
+	if d.Line == 0 || d.Offset > len(src) {
+		return b.String()
+	}
+
+	lineStart := strings.LastIndexByte(string(src[:d.Offset]), '\n') + 1
+
+	lineEnd := len(src)
+	if i := strings.IndexByte(string(src[d.Offset:]), '\n'); i != -1 {
+		lineEnd = d.Offset + i
+	}
+
+	b.WriteString(strings.TrimSuffix(string(src[lineStart:lineEnd]), "\r"))
+	b.WriteString("\n")
+
+	// Tabs are kept, so the caret is aligned the same way as the line
+	for _, r := range string(src[lineStart:d.Offset]) {
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+
+	b.WriteString(p.paint(colorRed, "^"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func (p Printer) paint(color, s string) string {
+	if !p.Color || s == "" {
+		return s
+	}
+
+	return color + s + colorReset
+}
+
+// WriteJSON writes diagnostics to w as a JSON array.
+func WriteJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(diags)
+}
@@ -0,0 +1,233 @@
+package diagnostic_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/diagnostic"
+	"github.com/flowtemplates/flow-go/formatter"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func TestPrinter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		err      func(input []byte) error
+		color    bool
+		expected string
+	}{
+		{
+			name:  "Render parse errors",
+			input: "Hello\n{{ a b }} and {{ + }}\n",
+			err: func(input []byte) error {
+				_, err := renderer.RenderBytes(input, renderer.Input{})
+
+				return err
+			},
+			expected: `
+main.flow:2:6: '}}' expected
+{{ a b }} and {{ + }}
+     ^
+main.flow:2:18: expression expected
+{{ a b }} and {{ + }}
+                 ^
+`[1:],
+		},
+		{
+			name:  "Format error with tabs",
+			input: "\t{% if %}{% end %}",
+			err: func(input []byte) error {
+				_, err := formatter.Bytes(input)
+
+				return err
+			},
+			expected: `
+main.flow:1:8: expression expected
+	{% if %}{% end %}
+	      ^
+`[1:],
+		},
+		{
+			name:  "Type errors",
+			input: "{{ a * 2 }}\n{{ a -> upper }}",
+			err: func(input []byte) error {
+				a := analyzer.New()
+				if err := a.TypeMapFromBytes(input); err != nil {
+					return err
+				}
+
+				return a.Errs.Err()
+			},
+			expected: `
+main.flow:2:4: TypeError: Variable 'a' expected type 'string'
+{{ a -> upper }}
+   ^
+`[1:],
+		},
+		{
+			name:  "Render errors",
+			input: "{{ 1 / 0 }}",
+			err: func(input []byte) error {
+				_, err := renderer.RenderBytes(input, renderer.Input{})
+
+				return err
+			},
+			expected: `
+main.flow:1:6: division by zero
+{{ 1 / 0 }}
+     ^
+`[1:],
+		},
+		{
+			name:  "Render error in nested expression",
+			input: "{{ (a.b -> upper) + 1 }}",
+			err: func(input []byte) error {
+				_, err := renderer.RenderBytes(input, renderer.Input{"a": 1})
+
+				return err
+			},
+			expected: `
+main.flow:1:7: cannot access field b of number
+{{ (a.b -> upper) + 1 }}
+      ^
+`[1:],
+		},
+		{
+			name:  "Parse error in extended template",
+			input: "{% extend \"base.flow\" %}",
+			err: func(input []byte) error {
+				env := &renderer.Environment{
+					Loader: renderer.MapLoader{"base.flow": "Base\n{{ a b }}"},
+				}
+				_, err := env.RenderBytes(input, renderer.Input{})
+
+				return err
+			},
+			expected: `
+base.flow:2:6: '}}' expected
+{{ a b }}
+     ^
+`[1:],
+		},
+		{
+			name:  "Render error in block of extended template",
+			input: "{% extend \"base.flow\" %}\n{% block title %}{{ p }}{% end %}",
+			err: func(input []byte) error {
+				env := &renderer.Environment{
+					Loader: renderer.MapLoader{
+						"base.flow": "{% block title %}{% end %}\n{% block body %}\n  {{ q }}\n{% end %}",
+					},
+				}
+				_, err := env.RenderBytes(input, renderer.Input{"p": 1})
+
+				return err
+			},
+			expected: `
+base.flow:3:6: q not declared
+  {{ q }}
+     ^
+`[1:],
+		},
+		{
+			name:  "Render error in overriding block",
+			input: "{% extend \"base.flow\" %}\n{% block body %}{{ q }}{% end %}",
+			err: func(input []byte) error {
+				env := &renderer.Environment{
+					Loader: renderer.MapLoader{"base.flow": "Base\n{% block body %}{% end %}"},
+				}
+				_, err := env.RenderBytes(input, renderer.Input{})
+
+				return err
+			},
+			expected: `
+main.flow:2:20: q not declared
+{% block body %}{{ q }}{% end %}
+                   ^
+`[1:],
+		},
+		{
+			name:  "Error without position",
+			input: "{{ a }}",
+			err: func([]byte) error {
+				return fmt.Errorf("load: %w", errors.New("template not found"))
+			},
+			expected: "main.flow: load: template not found\n",
+		},
+		{
+			name:  "Color",
+			input: "{{ a b }}",
+			err: func(input []byte) error {
+				_, err := renderer.RenderBytes(input, renderer.Input{})
+
+				return err
+			},
+			color:    true,
+			expected: "\x1b[1mmain.flow:1:6: \x1b[0m'}}' expected\n{{ a b }}\n     \x1b[1;31m^\x1b[0m\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := []byte(tc.input)
+			diags := diagnostic.FromError("main.flow", tc.err(input))
+
+			var buf bytes.Buffer
+			if err := (diagnostic.Printer{Color: tc.color}).Fprint(&buf, input, diags); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tc.expected {
+				t.Errorf("Input: %q\nMismatch.\nExpected:\n%q\nGot:\n%q", tc.input, tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestFromErrorWrapped(t *testing.T) {
+	_, err := renderer.RenderBytes([]byte("{{ a b }}"), renderer.Input{})
+	err = fmt.Errorf("template main.flow: %w", err)
+
+	diags := diagnostic.FromError("main.flow", err)
+	if len(diags) != 1 || diags[0].String() != "main.flow:1:6: '}}' expected" {
+		t.Errorf("Unexpected diagnostics: %v", diags)
+	}
+
+	if diagnostic.FromError("main.flow", nil) != nil {
+		t.Error("No diagnostics expected for nil error")
+	}
+
+	diags = diagnostic.FromError("", errors.New("oops"))
+	if len(diags) != 1 || diags[0].String() != "oops" {
+		t.Errorf("Unexpected diagnostics: %v", diags)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	_, err := formatter.Bytes([]byte("{{ a b }}"))
+
+	var buf bytes.Buffer
+	if err := diagnostic.WriteJSON(&buf, diagnostic.FromError("main.flow", err)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+[
+  {
+    "file": "main.flow",
+    "line": 1,
+    "column": 6,
+    "utf16Column": 6,
+    "offset": 5,
+    "message": "'}}' expected"
+  }
+]
+`[1:]
+
+	if buf.String() != expected {
+		t.Errorf("Mismatch.\nExpected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
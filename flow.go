@@ -0,0 +1,94 @@
+// Package flow compiles templates once to execute them many times.
+//
+//	tmpl, err := flow.Compile([]byte("Hello {{ name -> upper }}!"))
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = tmpl.Execute(os.Stdout, renderer.Input{"name": "world"})
+package flow
+
+import (
+	"context"
+	"io"
+	"maps"
+
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+// Template is a parsed and analyzed template.
+// It is safe to execute it from multiple goroutines at once.
+// Templates it extends are loaded and parsed by the first execution,
+// the later ones reuse them.
+type Template struct {
+	ast     parser.Ast
+	typeMap analyzer.TypeMap
+	env     *renderer.Environment
+}
+
+// Compile parses and analyzes the template with the built-in filters.
+func Compile(src []byte) (*Template, error) {
+	return CompileWith(&renderer.Environment{}, src)
+}
+
+// CompileWith parses and analyzes the template with filters, loader
+// and delimiters of env.
+// Filters are copied, so changes of env made after compilation don't affect
+// the template.
+//
+// The error is a [parser.ErrorList] if the template has syntax errors,
+// or [analyzer.TypeErrors] if it uses variables inconsistently
+// or calls undeclared filters, or *lexer.DelimsError if delimiters
+// of env are invalid.
+func CompileWith(env *renderer.Environment, src []byte) (*Template, error) {
+	ast, err := parser.AstFromBytesWith(src, env.Delims)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := renderer.DefaultFilters()
+	if env.Filters != nil {
+		filters = maps.Clone(env.Filters)
+	}
+
+	a := analyzer.New()
+	a.Filters = filters
+	a.TypeMapFromAst(ast)
+
+	if err := a.Errs.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Template{
+		ast:     ast,
+		typeMap: a.Tm,
+		env: &renderer.Environment{
+			Loader:  env.Loader,
+			Filters: filters,
+			Limits:  env.Limits,
+			Delims:  env.Delims,
+			// Templates loaded by {% extend %} are parsed on the first
+			// execution and reused by the later ones
+			Cache: &renderer.ParseCache{},
+		},
+	}, nil
+}
+
+// Execute renders the template with the input and writes the output to w.
+// The input is only read, so it may be shared between executions.
+func (t *Template) Execute(w io.Writer, input renderer.Input) error {
+	return t.env.Render(w, t.ast, input)
+}
+
+// ExecuteContext is like Execute, but stops when ctx is done.
+// Limits of the environment the template was compiled with are applied.
+func (t *Template) ExecuteContext(ctx context.Context, w io.Writer, input renderer.Input) error {
+	return t.env.RenderContext(ctx, w, t.ast, input)
+}
+
+// TypeMap returns types of the template input inferred from its usage.
+func (t *Template) TypeMap() analyzer.TypeMap {
+	return maps.Clone(t.typeMap)
+}
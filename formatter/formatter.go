@@ -5,17 +5,20 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/flowtemplates/flow-go/lexer"
 	"github.com/flowtemplates/flow-go/parser"
 	"github.com/flowtemplates/flow-go/token"
 )
 
 type formatter struct {
-	buf *bytes.Buffer
+	buf    *bytes.Buffer
+	delims lexer.Delims
 }
 
-func newFormatter() *formatter {
+func newFormatter(delims lexer.Delims) *formatter {
 	return &formatter{
-		buf: &bytes.Buffer{},
+		buf:    &bytes.Buffer{},
+		delims: delims,
 	}
 }
 
@@ -28,12 +31,30 @@ func (f *formatter) writeLineBreak() {
 }
 
 func (f *formatter) writeToken(kind token.Kind) {
-	f.buf.WriteString(kind.String())
+	f.buf.WriteString(f.delims.Of(kind))
 }
 
-func (f *formatter) writeClause(preWs string, tokens ...token.Kind) {
-	f.buf.WriteString(preWs)
-	f.writeToken(token.LSTMT)
+// writeOpening writes the opening delimiter of the tag with its trim marker.
+func (f *formatter) writeOpening(kind token.Kind, trim bool) {
+	f.writeToken(kind)
+
+	if trim {
+		f.buf.WriteRune(token.TrimMarker)
+	}
+}
+
+// writeClosing writes the closing delimiter of the tag with its trim marker.
+func (f *formatter) writeClosing(kind token.Kind, trim bool) {
+	if trim {
+		f.buf.WriteRune(token.TrimMarker)
+	}
+
+	f.writeToken(kind)
+}
+
+func (f *formatter) writeClause(tag parser.StmtTag, tokens ...token.Kind) {
+	f.buf.WriteString(tag.PreWs)
+	f.writeOpening(token.LSTMT, tag.TrimLeft)
 	f.writeSpace()
 
 	for _, t := range tokens {
@@ -41,13 +62,13 @@ func (f *formatter) writeClause(preWs string, tokens ...token.Kind) {
 		f.writeSpace()
 	}
 
-	f.writeToken(token.RSTMT)
+	f.writeClosing(token.RSTMT, tag.TrimRight)
 	f.writeLineBreak()
 }
 
-func (f *formatter) writeClauseWithExpr(preWs string, expr parser.Expr, tokens ...token.Kind) error {
-	f.buf.WriteString(preWs)
-	f.writeToken(token.LSTMT)
+func (f *formatter) writeClauseWithExpr(tag parser.StmtTag, expr parser.Expr, tokens ...token.Kind) error {
+	f.buf.WriteString(tag.PreWs)
+	f.writeOpening(token.LSTMT, tag.TrimLeft)
 	f.writeSpace()
 
 	for _, t := range tokens {
@@ -60,7 +81,36 @@ func (f *formatter) writeClauseWithExpr(preWs string, expr parser.Expr, tokens .
 	}
 
 	f.writeSpace()
-	f.writeToken(token.RSTMT)
+	f.writeClosing(token.RSTMT, tag.TrimRight)
+	f.writeLineBreak()
+
+	return nil
+}
+
+func (f *formatter) writeForTag(tag parser.ForTag) error {
+	f.buf.WriteString(tag.PreWs)
+	f.writeOpening(token.LSTMT, tag.TrimLeft)
+	f.writeSpace()
+	f.writeToken(token.FOR)
+	f.writeSpace()
+
+	if tag.Key != nil {
+		f.buf.WriteString(tag.Key.Name)
+		f.writeToken(token.COMMA)
+		f.writeSpace()
+	}
+
+	f.buf.WriteString(tag.Value.Name)
+	f.writeSpace()
+	f.writeToken(token.IN)
+	f.writeSpace()
+
+	if err := f.writeExpr(tag.Expr); err != nil {
+		return err
+	}
+
+	f.writeSpace()
+	f.writeClosing(token.RSTMT, tag.TrimRight)
 	f.writeLineBreak()
 
 	return nil
@@ -84,7 +134,7 @@ func (f *formatter) writeNode(node parser.Node) error {
 		f.buf.WriteString(n.PostLB)
 
 	case *parser.ExprNode:
-		f.writeToken(token.LEXPR)
+		f.writeOpening(token.LEXPR, n.TrimLeft)
 		f.writeSpace()
 
 		if err := f.writeExpr(n.Body); err != nil {
@@ -92,15 +142,75 @@ func (f *formatter) writeNode(node parser.Node) error {
 		}
 
 		f.writeSpace()
-		f.writeToken(token.REXPR)
+		f.writeClosing(token.REXPR, n.TrimRight)
 
 	case *parser.GenifNode:
-		if err := f.writeClauseWithExpr(n.PreWs, n.Expr, token.GENIF); err != nil {
+		if err := f.writeClauseWithExpr(n.StmtTag, n.Expr, token.GENIF); err != nil {
 			return err
 		}
 
+	case *parser.LetNode:
+		f.buf.WriteString(n.PreWs)
+		f.writeOpening(token.LSTMT, n.TrimLeft)
+		f.writeSpace()
+		f.writeToken(token.LET)
+		f.writeSpace()
+		f.buf.WriteString(n.Name.Name)
+		f.writeSpace()
+		f.writeToken(token.ASSIGN)
+		f.writeSpace()
+
+		if err := f.writeExpr(n.Expr); err != nil {
+			return err
+		}
+
+		f.writeSpace()
+		f.writeClosing(token.RSTMT, n.TrimRight)
+		f.writeLineBreak()
+
+	case *parser.ExtendNode:
+		f.buf.WriteString(n.PreWs)
+		f.writeOpening(token.LSTMT, n.TrimLeft)
+		f.writeSpace()
+		f.writeToken(token.EXTEND)
+		f.writeSpace()
+
+		if err := f.writeExpr(&n.Path); err != nil {
+			return err
+		}
+
+		f.writeSpace()
+		f.writeClosing(token.RSTMT, n.TrimRight)
+		f.writeLineBreak()
+
+	case *parser.BlockNode:
+		if err := f.writeClauseWithExpr(n.BlockTag.StmtTag, &n.BlockTag.Name, token.BLOCK); err != nil {
+			return err
+		}
+
+		for _, node := range n.Body {
+			if err := f.writeNode(node); err != nil {
+				return err
+			}
+		}
+
+		f.writeClause(n.EndTag, token.END)
+
+	case *parser.RawNode:
+		f.buf.WriteString(n.RawTag.PreWs)
+		f.writeOpening(token.LSTMT, n.RawTag.TrimLeft)
+		f.writeSpace()
+		f.writeToken(token.RAW)
+		f.writeSpace()
+		f.writeClosing(token.RSTMT, n.RawTag.TrimRight)
+
+		// Text is written as is, including line breaks after and before the tags
+		f.buf.WriteString(n.Val)
+
+		f.writeClause(n.EndTag, token.END)
+
 	case *parser.IfNode:
-		if err := f.writeClauseWithExpr(n.IfTag.PreWs, n.IfTag.Expr, token.IF); err != nil {
+		if err := f.writeClauseWithExpr(n.IfTag.StmtTag, n.IfTag.Expr, token.IF); err != nil {
 			return err
 		}
 
@@ -111,7 +221,7 @@ func (f *formatter) writeNode(node parser.Node) error {
 		}
 
 		for _, elseIf := range n.ElseIfs {
-			if err := f.writeClauseWithExpr(elseIf.Tag.PreWs, elseIf.Tag.Expr, token.ELSE, token.IF); err != nil {
+			if err := f.writeClauseWithExpr(elseIf.Tag.StmtTag, elseIf.Tag.Expr, token.ELSE, token.IF); err != nil {
 				return err
 			}
 
@@ -123,7 +233,7 @@ func (f *formatter) writeNode(node parser.Node) error {
 		}
 
 		if len(n.Else.Body) > 0 {
-			f.writeClause(n.Else.Tag.PreWs, token.ELSE)
+			f.writeClause(n.Else.Tag, token.ELSE)
 
 			for _, node := range n.Else.Body {
 				if err := f.writeNode(node); err != nil {
@@ -132,15 +242,15 @@ func (f *formatter) writeNode(node parser.Node) error {
 			}
 		}
 
-		f.writeClause(n.EndTag.PreWs, token.END)
+		f.writeClause(n.EndTag, token.END)
 
 	case *parser.SwitchNode:
-		if err := f.writeClauseWithExpr(n.SwitchTag.PreWs, n.SwitchTag.Expr, token.SWITCH); err != nil {
+		if err := f.writeClauseWithExpr(n.SwitchTag.StmtTag, n.SwitchTag.Expr, token.SWITCH); err != nil {
 			return err
 		}
 
 		for _, cc := range n.Cases {
-			if err := f.writeClauseWithExpr(cc.Tag.PreWs, cc.Tag.Expr, token.CASE); err != nil {
+			if err := f.writeClauseWithExpr(cc.Tag.StmtTag, cc.Tag.Expr, token.CASE); err != nil {
 				return err
 			}
 
@@ -152,7 +262,7 @@ func (f *formatter) writeNode(node parser.Node) error {
 		}
 
 		if n.DefaultCase != nil {
-			f.writeClause(n.DefaultCase.Tag.PreWs, token.DEFAULT)
+			f.writeClause(n.DefaultCase.Tag, token.DEFAULT)
 
 			for _, node := range n.DefaultCase.Body {
 				if err := f.writeNode(node); err != nil {
@@ -161,7 +271,30 @@ func (f *formatter) writeNode(node parser.Node) error {
 			}
 		}
 
-		f.writeClause(n.EndTag.PreWs, token.END)
+		f.writeClause(n.EndTag, token.END)
+
+	case *parser.ForNode:
+		if err := f.writeForTag(n.ForTag); err != nil {
+			return err
+		}
+
+		for _, node := range n.Body {
+			if err := f.writeNode(node); err != nil {
+				return err
+			}
+		}
+
+		if len(n.Else.Body) > 0 {
+			f.writeClause(n.Else.Tag, token.ELSE)
+
+			for _, node := range n.Else.Body {
+				if err := f.writeNode(node); err != nil {
+					return err
+				}
+			}
+		}
+
+		f.writeClause(n.EndTag, token.END)
 
 	default:
 		return fmt.Errorf("unknown node type: %s", n)
@@ -173,10 +306,20 @@ func (f *formatter) writeNode(node parser.Node) error {
 func (f *formatter) writeExpr(expr parser.Expr) error {
 	switch e := expr.(type) {
 	case *parser.StringLit:
+		// Literals are kept as written, escapes included
+		if e.Raw != "" {
+			f.buf.WriteString(e.Raw)
+
+			break
+		}
+
 		f.buf.WriteByte(e.Quote)
 		f.buf.WriteString(e.Value.AsString())
 		f.buf.WriteByte(e.Quote)
 
+	case *parser.InterpolatedLit:
+		f.buf.WriteString(e.Raw)
+
 	case *parser.NumberLit:
 		f.buf.WriteString(e.Value.AsString())
 
@@ -237,6 +380,27 @@ func (f *formatter) writeExpr(expr parser.Expr) error {
 			return err
 		}
 
+	case *parser.SelectorExpr:
+		if err := f.writeExpr(e.X); err != nil {
+			return err
+		}
+
+		f.writeToken(token.PERIOD)
+		f.buf.WriteString(e.Sel.Name)
+
+	case *parser.IndexExpr:
+		if err := f.writeExpr(e.X); err != nil {
+			return err
+		}
+
+		f.writeToken(token.LBRACK)
+
+		if err := f.writeExpr(e.Index); err != nil {
+			return err
+		}
+
+		f.writeToken(token.RBRACK)
+
 	case *parser.FilterExpr:
 		if err := f.writeExpr(e.Expr); err != nil {
 			return err
@@ -248,6 +412,23 @@ func (f *formatter) writeExpr(expr parser.Expr) error {
 
 		f.buf.WriteString(e.Filter.Name)
 
+		if e.Args != nil {
+			f.writeToken(token.LPAREN)
+
+			for i, arg := range e.Args {
+				if i > 0 {
+					f.writeToken(token.COMMA)
+					f.writeSpace()
+				}
+
+				if err := f.writeExpr(arg); err != nil {
+					return err
+				}
+			}
+
+			f.writeToken(token.RPAREN)
+		}
+
 	default:
 		return fmt.Errorf("unknown expression type: %T", e)
 	}
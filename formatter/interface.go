@@ -3,24 +3,46 @@ package formatter
 import (
 	"fmt"
 
+	"github.com/flowtemplates/flow-go/lexer"
 	"github.com/flowtemplates/flow-go/parser"
 )
 
 func Bytes(input []byte) ([]byte, error) {
-	ast, err := parser.AstFromBytes(input)
+	return BytesWith(input, lexer.DefaultDelims)
+}
+
+// BytesWith formats the template written with the given delimiters,
+// they are kept in the output.
+func BytesWith(input []byte, delims lexer.Delims) ([]byte, error) {
+	ast, err := parser.AstFromBytesWith(input, delims)
 	if err != nil {
 		return nil, fmt.Errorf("ast parsing: %w", err)
 	}
 
-	return Ast(ast)
+	return AstWith(ast, delims)
 }
 
 func Ast(ast parser.Ast) ([]byte, error) {
-	f := newFormatter()
-	for _, node := range ast {
+	return AstWith(ast, lexer.DefaultDelims)
+}
+
+// AstWith formats the template with the given delimiters. If the template
+// starts with the pragma, the rest of it is written with delimiters of
+// the pragma, as it was lexed.
+func AstWith(ast parser.Ast, delims lexer.Delims) ([]byte, error) {
+	f := newFormatter(delims)
+	for i, node := range ast {
 		if err := f.writeNode(node); err != nil {
 			return nil, err
 		}
+
+		if i == 0 {
+			if comm, ok := node.(*parser.CommNode); ok {
+				if pragma, ok, err := lexer.ParsePragma(comm.Val); ok && err == nil {
+					f.delims = pragma
+				}
+			}
+		}
 	}
 
 	return f.buf.Bytes(), nil
@@ -0,0 +1,41 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/formatter"
+	"github.com/flowtemplates/flow-go/lexer"
+)
+
+func TestCustomDelimsUnchanged(t *testing.T) {
+	testCases := []unchangedTestCase{
+		{
+			name: "Delimiters of the pragma",
+			input: `
+{# flow:delims [[ ]] [% %] [# #] #}
+[# Values of the chart #]
+name: {{ .Values.name }}-[[ name -> lower ]]
+[% if enabled %]
+enabled: true
+[% end %]
+[%- raw -%]{{ x }}[% end %]
+`[1:],
+		},
+	}
+	runUnchangedTestCases(t, testCases)
+}
+
+func TestCustomDelims(t *testing.T) {
+	delims := lexer.Delims{LExpr: "<%=", RExpr: "%>", LStmt: "<%", RStmt: "%>", LComm: "<%#", RComm: "%>"}
+	input := "<%#comment%>\n<%=name->upper-%>\n<%if  ok%>{{ x }}<%end%>"
+	expected := "<%# comment %>\n<%= name -> upper -%>\n<% if ok %>\n{{ x }}<% end %>\n"
+
+	got, err := formatter.BytesWith([]byte(input), delims)
+	if err != nil {
+		t.Fatalf("Input: %q\nUnexpected error: %v", input, err)
+	}
+
+	if string(got) != expected {
+		t.Errorf("Input: %q\nMismatch.\nExpected:\n%q\nGot:\n%q", input, expected, got)
+	}
+}
@@ -239,6 +239,28 @@ text
 			name: "Nested filters",
 			input: `
 {{ name -> upper -> camel }}
+`[1:],
+		},
+		{
+			name: "Filter with arguments",
+			input: `
+{{ name -> replace("-", "_") -> upper() }}
+`[1:],
+		},
+		{
+			name: "String literals with escapes",
+			input: `
+{{ "say \"hi\"\n" }} {{ 'it\'s \u00e9' }}
+`[1:],
+		},
+		{
+			name:  "Raw string literal",
+			input: "{{ `C:\\dir\\${x}` }}\n",
+		},
+		{
+			name: "Interpolated string literal",
+			input: `
+{{ "Hello, ${name->upper}! \${x}" }}
 `[1:],
 		},
 	}
@@ -281,6 +303,33 @@ func TestExpressions(t *testing.T) {
 `[1:],
 			expected: `
 {{ -1.1 }}
+`[1:],
+		},
+		{
+			name: "Member access",
+			input: `
+{{user.name}} {{ items[ 0 ].name}}
+`[1:],
+			expected: `
+{{ user.name }} {{ items[0].name }}
+`[1:],
+		},
+		{
+			name: "Arithmetic",
+			input: `
+{{a+b*-c%2}}
+`[1:],
+			expected: `
+{{ a + b * -c % 2 }}
+`[1:],
+		},
+		{
+			name: "Arithmetic with parens",
+			input: `
+{{(a-1)/ 2}}
+`[1:],
+			expected: `
+{{ (a - 1) / 2 }}
 `[1:],
 		},
 		{
@@ -379,6 +428,15 @@ text
 `[1:],
 			expected: `
 {{ name -> upper }}
+`[1:],
+		},
+		{
+			name: "Filter with arguments",
+			input: `
+{{name->replace( "-",x+1 )}}
+`[1:],
+			expected: `
+{{ name -> replace("-", x + 1) }}
 `[1:],
 		},
 		{
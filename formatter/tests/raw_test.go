@@ -0,0 +1,26 @@
+package renderer_test
+
+import (
+	"testing"
+)
+
+func TestRawStatementsUnchanged(t *testing.T) {
+	testCases := []unchangedTestCase{
+		{
+			name: "Raw statement",
+			input: `
+{% raw %}
+image: {{.Values.image}}
+  {{- if   .Values.enabled }}{%if%}
+{% end %}
+`[1:],
+		},
+		{
+			name: "Raw statement with trim markers",
+			input: `
+{%- raw -%}  {{x}}   {%- end %}
+`[1:],
+		},
+	}
+	runUnchangedTestCases(t, testCases)
+}
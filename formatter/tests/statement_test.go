@@ -115,6 +115,39 @@ Text
 			name: "Simple genif",
 			input: `
 {% genif true %}
+`[1:],
+		},
+		{
+			name: "For",
+			input: `
+{% for item in items %}
+{{ item }}
+{% end %}
+`[1:],
+		},
+		{
+			name: "For-else with index",
+			input: `
+{% for i, item in items %}
+{{ i }}
+{% else %}
+empty
+{% end %}
+`[1:],
+		},
+		{
+			name: "Let",
+			input: `
+{% let a = name -> upper %}
+{{ a }}`[1:],
+		},
+		{
+			name: "Extend with block",
+			input: `
+{% extend "base.flow" %}
+{% block body %}
+text
+{% end %}
 `[1:],
 		},
 	}
@@ -168,6 +201,28 @@ Text
 {% default %}
 456
 {% end %}
+`[1:],
+		},
+		{
+			name: "For",
+			input: `
+{%for i,item in   items%}
+{{item}}
+{%end %}
+`[1:],
+			expected: `
+{% for i, item in items %}
+{{ item }}
+{% end %}
+`[1:],
+		},
+		{
+			name: "Let",
+			input: `
+{%let a=1%}
+`[1:],
+			expected: `
+{% let a = 1 %}
 `[1:],
 		},
 	}
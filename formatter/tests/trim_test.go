@@ -0,0 +1,40 @@
+package renderer_test
+
+import (
+	"testing"
+)
+
+func TestTrimMarkersUnchanged(t *testing.T) {
+	testCases := []unchangedTestCase{
+		{
+			name:  "Expression with trim markers",
+			input: "a {{- name -}} b",
+		},
+		{
+			name: "Statements with trim markers",
+			input: `
+{%- if var -%}
+  text
+{%- else -%}
+  2
+{%- end -%}
+`[1:],
+		},
+		{
+			name: "Loop with trim markers",
+			input: `
+{% for i, v in items -%}
+  {{ v }}
+{%- end %}
+`[1:],
+		},
+		{
+			name: "Let and extend with trim markers",
+			input: `
+{%- extend "base.flow" -%}
+{%- let a = "x" -%}
+`[1:],
+		},
+	}
+	runUnchangedTestCases(t, testCases)
+}
@@ -2,4 +2,7 @@ module github.com/flowtemplates/flow-go
 
 go 1.24.0
 
-require github.com/iancoleman/strcase v0.3.0
+require (
+	github.com/iancoleman/strcase v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,183 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Delims are delimiters of expression, statement and comment tags.
+// Empty fields are replaced with the default delimiters.
+type Delims struct {
+	LExpr, RExpr string
+	LStmt, RStmt string
+	LComm, RComm string
+}
+
+// DefaultDelims are '{{ }}', '{% %}' and '{# #}'.
+var DefaultDelims = Delims{
+	LExpr: token.LEXPR.String(),
+	RExpr: token.REXPR.String(),
+	LStmt: token.LSTMT.String(),
+	RStmt: token.RSTMT.String(),
+	LComm: token.LCOMM.String(),
+	RComm: token.RCOMM.String(),
+}
+
+// PragmaPrefix starts the comment at the beginning of the template which
+// changes delimiters of the rest of it, e.g. '{# flow:delims [[ ]] [% %] [# #] #}'.
+// Pairs of delimiters follow it in order: expression, statement, comment.
+const PragmaPrefix = "flow:delims"
+
+// DelimsError is returned for delimiters that can't be lexed unambiguously.
+type DelimsError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *DelimsError) Error() string {
+	return "invalid delimiters: " + e.Msg
+}
+
+func (e *DelimsError) Position() token.Position {
+	return e.Pos
+}
+
+// Of returns the delimiter of the given kind, or the kind itself
+// if it is not a delimiter.
+func (d Delims) Of(k token.Kind) string {
+	var delim string
+
+	switch k { //nolint: exhaustive
+	case token.LEXPR:
+		delim = d.LExpr
+	case token.REXPR:
+		delim = d.RExpr
+	case token.LSTMT:
+		delim = d.LStmt
+	case token.RSTMT:
+		delim = d.RStmt
+	case token.LCOMM:
+		delim = d.LComm
+	case token.RCOMM:
+		delim = d.RComm
+	}
+
+	if delim == "" {
+		return k.String()
+	}
+
+	return delim
+}
+
+func (d Delims) withDefaults() Delims {
+	fields := []*string{&d.LExpr, &d.RExpr, &d.LStmt, &d.RStmt, &d.LComm, &d.RComm}
+	defaults := []string{
+		DefaultDelims.LExpr, DefaultDelims.RExpr,
+		DefaultDelims.LStmt, DefaultDelims.RStmt,
+		DefaultDelims.LComm, DefaultDelims.RComm,
+	}
+
+	for i, f := range fields {
+		if *f == "" {
+			*f = defaults[i]
+		}
+	}
+
+	return d
+}
+
+// Validate reports whether the delimiters can be lexed. They must not
+// contain whitespace or have the trim marker on the side of the tag body,
+// and opening delimiters must differ. If one of them is a prefix of another,
+// the longest one is matched.
+func (d Delims) Validate() error {
+	d = d.withDefaults()
+
+	for _, delim := range []string{d.LExpr, d.LStmt, d.LComm} {
+		if strings.HasSuffix(delim, string(token.TrimMarker)) {
+			return &DelimsError{Msg: fmt.Sprintf("%q ends with the trim marker", delim)}
+		}
+	}
+
+	for _, delim := range []string{d.RExpr, d.RStmt, d.RComm} {
+		if strings.HasPrefix(delim, string(token.TrimMarker)) {
+			return &DelimsError{Msg: fmt.Sprintf("%q starts with the trim marker", delim)}
+		}
+	}
+
+	all := []string{d.LExpr, d.RExpr, d.LStmt, d.RStmt, d.LComm, d.RComm}
+	for _, delim := range all {
+		if strings.IndexFunc(delim, unicode.IsSpace) != -1 {
+			return &DelimsError{Msg: fmt.Sprintf("%q contains whitespace", delim)}
+		}
+	}
+
+	if d.LExpr == d.LStmt || d.LExpr == d.LComm || d.LStmt == d.LComm {
+		return &DelimsError{Msg: "opening delimiters must differ"}
+	}
+
+	return nil
+}
+
+// Pragma returns delimiters set by the pragma comment at the beginning of
+// the source, which is written with delimiters d. If there is no pragma,
+// d is returned as is. If the pragma is invalid, d is returned with the error.
+func Pragma(source []byte, d Delims) (Delims, error) {
+	d = d.withDefaults()
+
+	start := bytes.TrimLeft(source, " \t")
+
+	body, ok := bytes.CutPrefix(start, []byte(d.LComm))
+	if !ok {
+		return d, nil
+	}
+
+	end := bytes.Index(body, []byte(d.RComm))
+	if end == -1 {
+		return d, nil
+	}
+
+	pragma, ok, err := ParsePragma(string(body[:end]))
+	if !ok {
+		return d, nil
+	}
+
+	if err != nil {
+		err.(*DelimsError).Pos = startPos.Advance(string(source[:len(source)-len(start)])) //nolint: forcetypeassert
+
+		return d, err
+	}
+
+	return pragma, nil
+}
+
+// ParsePragma parses the text of the pragma comment, ok is false
+// if the comment is not a pragma.
+func ParsePragma(comment string) (d Delims, ok bool, err error) {
+	fields := strings.Fields(comment)
+	if len(fields) == 0 || fields[0] != PragmaPrefix {
+		return Delims{}, false, nil
+	}
+
+	if len(fields) != 7 {
+		return Delims{}, true, &DelimsError{
+			Msg: "pragma must set expression, statement and comment delimiters",
+		}
+	}
+
+	d = Delims{
+		LExpr: fields[1], RExpr: fields[2],
+		LStmt: fields[3], RStmt: fields[4],
+		LComm: fields[5], RComm: fields[6],
+	}
+
+	if err := d.Validate(); err != nil {
+		return Delims{}, true, err
+	}
+
+	return d, true, nil
+}
@@ -0,0 +1,141 @@
+package lexer
+
+import (
+	"sort"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Edit replaces Deleted bytes of the source at Offset with Text.
+type Edit struct {
+	Offset  int
+	Deleted int
+	Text    string
+}
+
+// Apply returns the source changed by the edit.
+func (e Edit) Apply(source []byte) []byte {
+	res := make([]byte, 0, len(source)+e.Delta())
+	res = append(res, source[:e.Offset]...)
+	res = append(res, e.Text...)
+
+	return append(res, source[e.Offset+e.Deleted:]...)
+}
+
+// Delta returns the change of the source length.
+func (e Edit) Delta() int {
+	return len(e.Text) - e.Deleted
+}
+
+// PragmaEnd returns the offset up to which edits of the source may change
+// its pragma: the end of the first comment if the source starts with one,
+// otherwise the start of its first token that is not whitespace.
+func PragmaEnd(tokens []token.Token) int {
+	for i, tok := range tokens {
+		switch tok.Kind { //nolint: exhaustive
+		case token.WS:
+			continue
+
+		case token.LCOMM:
+			for _, t := range tokens[i:] {
+				if t.Kind == token.RCOMM {
+					return t.End().Offset
+				}
+			}
+
+			return tokens[len(tokens)-1].End().Offset
+
+		default:
+			return tok.Pos.Offset
+		}
+	}
+
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	return tokens[len(tokens)-1].End().Offset
+}
+
+func Relex(tokens []token.Token, source []byte, edit Edit) []token.Token {
+	return RelexWith(tokens, source, edit, DefaultDelims)
+}
+
+// RelexWith returns tokens of the source changed by the edit, given tokens
+// of the source before it lexed with the same delimiters. Only the lines
+// from the one the edit starts on are lexed, up to the first line break
+// after the edit that is in the old tokens too, the rest of the tokens
+// are reused. Edits that may change the pragma make the whole source lexed.
+func RelexWith(tokens []token.Token, source []byte, edit Edit, delims Delims) []token.Token {
+	if len(tokens) == 0 || edit.Offset <= PragmaEnd(tokens) {
+		return TokensFromBytesWith(source, delims)
+	}
+
+	// Line breaks are lexed only in text, so lines always start in the same state
+	start := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].End().Offset > edit.Offset
+	})
+	for start > 0 && tokens[start-1].Kind != token.LNBR {
+		start--
+	}
+
+	var l *lexer
+
+	if start == 0 {
+		l = initLexer(source, delims)
+	} else {
+		// The pragma is before the line, so its delimiters are already in use
+		d := delims.withDefaults()
+		if pragma, err := Pragma(source, d); err == nil {
+			d = pragma
+		}
+
+		pos := tokens[start-1].End()
+		l = &lexer{source: source, text: string(source), startPos: pos, pos: pos}
+		l.setDelims(d)
+	}
+
+	res := make([]token.Token, start, len(tokens)+1)
+	copy(res, tokens[:start])
+
+	insertedEnd := edit.Offset + len(edit.Text)
+	delta := edit.Delta()
+
+	for state := lexLineWhitespace(lexText); state != nil; {
+		var emitted []token.Token
+
+		state, emitted = l.step(state)
+		res = append(res, emitted...)
+
+		if len(emitted) == 0 {
+			continue
+		}
+
+		last := emitted[len(emitted)-1]
+		if last.Kind != token.LNBR || last.Pos.Offset < insertedEnd {
+			continue
+		}
+
+		oldOffset := last.Pos.Offset - delta
+
+		i := sort.Search(len(tokens), func(i int) bool {
+			return tokens[i].Pos.Offset >= oldOffset
+		})
+		if i == len(tokens) || tokens[i].Pos.Offset != oldOffset || tokens[i].Kind != token.LNBR {
+			continue
+		}
+
+		// The rest of the source is the same, only lines and offsets are moved
+		lineDelta := last.Pos.Line - tokens[i].Pos.Line
+
+		for _, tok := range tokens[i+1:] {
+			tok.Pos.Offset += delta
+			tok.Pos.Line += lineDelta
+			res = append(res, tok)
+		}
+
+		return res
+	}
+
+	return res
+}
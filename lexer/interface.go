@@ -5,36 +5,51 @@ import (
 )
 
 type lexer struct {
-	source   []byte
+	source []byte
+	// Source as a string, values of tokens are its substrings,
+	// so they are not allocated one by one
+	text     string
 	startPos token.Position
 	pos      token.Position
-	tokensCh chan token.Token
+	// Tokens emitted by the current state
+	pending []token.Token
+	delims  Delims
+	// First bytes of the delimiters, other text is skipped without matching them
+	delimStarts [256]bool
+	// Delimiters set by the pragma, they are used after it is lexed
+	pragma *Delims
 }
 
-func newLexer(input []byte) *lexer {
+// initLexer returns the lexer at the beginning of the input.
+func initLexer(input []byte, delims Delims) *lexer {
 	l := lexer{
-		source: input,
-		startPos: token.Position{
-			Offset: 0,
-			Line:   1,
-			Column: 1,
-		},
-		tokensCh: make(chan token.Token, 2),
+		source:   input,
+		text:     string(input),
+		startPos: startPos,
 	}
 	l.pos = l.startPos
+	l.setDelims(delims.withDefaults())
 
-	go l.run()
+	if d, err := Pragma(input, l.delims); err == nil && d != l.delims {
+		l.pragma = &d
+	}
 
 	return &l
 }
 
 func TokensFromBytes(source []byte) []token.Token {
-	l := newLexer(source)
+	return TokensFromBytesWith(source, DefaultDelims)
+}
+
+// TokensFromBytesWith lexes the source with the given delimiters,
+// unless they are changed by the pragma.
+func TokensFromBytesWith(source []byte, delims Delims) []token.Token {
+	s := NewScannerWith(source, delims)
 
 	var tokens []token.Token
 
 	for {
-		tok := <-l.tokensCh
+		tok := s.Next()
 		if tok.Kind == token.EOF {
 			break
 		}
@@ -45,8 +60,60 @@ func TokensFromBytes(source []byte) []token.Token {
 	return tokens
 }
 
+// ExprTokens lexes the source as an expression in a tag, e.g. the one
+// interpolated into a string literal. Tokens are positioned as if the
+// source started at pos, it must not contain line breaks.
+func ExprTokens(source []byte, pos token.Position) []token.Token {
+	l := lexer{
+		source: source,
+		text:   string(source),
+		// Offsets index the source, they are shifted after lexing
+		startPos: token.Position{Line: pos.Line, Column: pos.Column, UTF16Column: pos.UTF16Column},
+	}
+	l.pos = l.startPos
+	l.setDelims(DefaultDelims)
+
+	var tokens []token.Token
+
+	for state := stateFn(lexExpr); state != nil; {
+		var emitted []token.Token
+
+		state, emitted = l.step(state)
+		tokens = append(tokens, emitted...)
+	}
+
+	for i := range tokens {
+		tokens[i].Pos.Offset += pos.Offset
+	}
+
+	return tokens
+}
+
+// ChanFromBytes lexes the source on a separate goroutine. The channel must
+// be drained up to its end, otherwise the goroutine is never finished,
+// Scanner has no such restriction.
 func ChanFromBytes(source []byte) <-chan token.Token {
-	l := newLexer(source)
+	s := NewScanner(source)
+	ch := make(chan token.Token, 2)
+
+	go func() {
+		for tok := s.Next(); tok.Kind != token.EOF; tok = s.Next() {
+			ch <- tok
+		}
+
+		close(ch)
+	}()
+
+	return ch
+}
+
+func (l *lexer) setDelims(d Delims) {
+	l.delims = d
+	l.delimStarts = [256]bool{}
 
-	return l.tokensCh
+	for _, k := range []token.Kind{
+		token.LEXPR, token.REXPR, token.LSTMT, token.RSTMT, token.LCOMM, token.RCOMM, token.RARR,
+	} {
+		l.delimStarts[d.Of(k)[0]] = true
+	}
 }
@@ -1,29 +1,38 @@
 package lexer
 
 import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+
 	"github.com/flowtemplates/flow-go/token"
 )
 
-const eof = 0
+// eof is returned at the end of the source, it is not a valid rune,
+// so NUL characters of the source are read as they are.
+const eof = -1
+
+// startPos is the position of the beginning of the source.
+var startPos = token.Position{Line: 1, Column: 1, UTF16Column: 1}
 
 func (l *lexer) emit(t token.Kind) {
 	if l.startPos.Offset < l.pos.Offset {
-		l.tokensCh <- token.Token{
+		l.pending = append(l.pending, token.Token{
 			Kind: t,
-			Val:  string(l.source[l.startPos.Offset:l.pos.Offset]),
+			Val:  l.text[l.startPos.Offset:l.pos.Offset],
 			Pos:  l.startPos,
-		}
+		})
 
 		l.startPos = l.pos
 	}
 }
 
-func (l *lexer) run() {
-	for state := lexLineWhitespace(lexText); state != nil; {
-		state = state(l)
-	}
+// step runs the state and returns tokens it emitted, which are valid
+// until the next step.
+func (l *lexer) step(state stateFn) (stateFn, []token.Token) {
+	l.pending = l.pending[:0]
 
-	close(l.tokensCh)
+	return state(l), l.pending
 }
 
 func (l *lexer) next() rune {
@@ -31,41 +40,45 @@ func (l *lexer) next() rune {
 		return eof
 	}
 
-	r := rune(l.source[l.pos.Offset])
-	l.pos.Offset++
-
-	// Handle newline properly
-	if r == '\n' {
-		l.pos.Line++
-		l.pos.Column = 1 // Reset column on new line
-	} else {
-		l.pos.Column++
-	}
+	r, size := utf8.DecodeRune(l.source[l.pos.Offset:])
+	l.pos = l.pos.Advance(l.text[l.pos.Offset : l.pos.Offset+size])
 
 	return r
 }
 
+// skip moves n bytes forward.
+func (l *lexer) skip(n int) {
+	l.pos = l.pos.Advance(l.text[l.pos.Offset : l.pos.Offset+n])
+}
+
+// back moves one character back.
 func (l *lexer) back() {
 	if l.pos.Offset <= 0 {
 		return
 	}
 
-	l.pos.Offset--
-	if l.source[l.pos.Offset] == '\n' {
-		l.pos.Line-- // Move back a line
-		// Find previous line start to restore column correctly
-		l.pos.Column = 1
-		for i := l.pos.Offset - 1; i >= 0 && l.source[i] != '\n'; i-- {
-			l.pos.Column++
-		}
-	} else {
+	r, size := utf8.DecodeLastRune(l.source[:l.pos.Offset])
+	l.pos.Offset -= size
+
+	if r != '\n' {
 		l.pos.Column--
+		l.pos.UTF16Column -= utf16.RuneLen(r)
+
+		return
 	}
+
+	// Column of the line end is counted from the line start
+	lineStart := bytes.LastIndexByte(l.source[:l.pos.Offset], '\n') + 1
+	line := l.pos.Line - 1
+
+	l.pos = startPos.Advance(l.text[lineStart:l.pos.Offset])
+	l.pos.Line = line
+	l.pos.Offset = lineStart + l.pos.Offset
 }
 
 func (l *lexer) peek() rune {
 	if l.pos.Offset < len(l.source) {
-		r := rune(l.source[l.pos.Offset])
+		r, _ := utf8.DecodeRune(l.source[l.pos.Offset:])
 
 		return r
 	}
@@ -75,6 +88,10 @@ func (l *lexer) peek() rune {
 
 func (l *lexer) accept(valid string) bool {
 	r := l.next()
+	if r == eof {
+		return false
+	}
+
 	for _, c := range valid {
 		if c == r {
 			return true
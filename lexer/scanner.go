@@ -0,0 +1,43 @@
+package lexer
+
+import (
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Scanner lexes the source on demand, on the goroutine of its caller.
+type Scanner struct {
+	l     *lexer
+	state stateFn
+	// Tokens lexed but not returned yet
+	buf []token.Token
+}
+
+func NewScanner(source []byte) *Scanner {
+	return NewScannerWith(source, DefaultDelims)
+}
+
+// NewScannerWith returns the scanner of the source written with the given
+// delimiters, unless they are changed by the pragma.
+func NewScannerWith(source []byte, delims Delims) *Scanner {
+	return &Scanner{
+		l:     initLexer(source, delims),
+		state: lexLineWhitespace(lexText),
+	}
+}
+
+// Next returns the next token of the source, or the EOF token
+// at the end of it. Calls after the end return EOF as well.
+func (s *Scanner) Next() token.Token {
+	for len(s.buf) == 0 {
+		if s.state == nil {
+			return token.Token{Kind: token.EOF, Pos: s.l.pos}
+		}
+
+		s.state, s.buf = s.l.step(s.state)
+	}
+
+	tok := s.buf[0]
+	s.buf = s.buf[1:]
+
+	return tok
+}
@@ -2,61 +2,130 @@ package lexer
 
 import (
 	"bytes"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/flowtemplates/flow-go/token"
 )
 
 type stateFn func(*lexer) stateFn
 
+// Kinds of tokens tried in tags, the getters allocate on each call
+var (
+	operators          = token.GetOperators()
+	operatorsWithoutKw = token.GetOperatorsWithoutKw()
+	keywords           = token.GetKeywords()
+)
+
 func (l *lexer) lexToken(t token.Kind, next stateFn) stateFn {
-	tokLen := len(t.String())
-	l.pos.Offset += tokLen
-	l.pos.Column += tokLen
+	l.skip(len(l.delims.Of(t)))
+	l.emit(t)
+
+	return next
+}
+
+// lexOpening lexes the opening delimiter of the tag with the trim marker
+// following it. '{{-' is the marker only if it is followed by whitespace,
+// so '{{-1}}' is still a negative number.
+func (l *lexer) lexOpening(t token.Kind, next stateFn) stateFn {
+	tokLen := len(l.delims.Of(t))
+	rest := l.source[l.pos.Offset+tokLen:]
+
+	if len(rest) > 0 && rest[0] == token.TrimMarker {
+		if r, _ := utf8.DecodeRune(rest[1:]); t != token.LEXPR || unicode.IsSpace(r) {
+			tokLen++
+		}
+	}
+
+	l.skip(tokLen)
+	l.emit(t)
+
+	return next
+}
+
+// tryTrimmedClosing lexes the closing delimiter of the tag preceded by
+// the trim marker, e.g. '-%}'.
+func (l *lexer) tryTrimmedClosing(t token.Kind, next stateFn) stateFn {
+	delim := l.delims.Of(t)
+
+	rest := l.source[l.pos.Offset:]
+	if len(rest) == 0 || rest[0] != token.TrimMarker || !bytes.HasPrefix(rest[1:], []byte(delim)) {
+		return nil
+	}
+
+	l.skip(len(delim) + 1)
 	l.emit(t)
 
 	return next
 }
 
 func (l *lexer) startsWith(t token.Kind) bool {
-	tokBytes := t.Bytes()
-	if len(tokBytes) > 0 {
-		return bytes.HasPrefix(l.source[l.pos.Offset:], tokBytes)
+	delim := l.delims.Of(t)
+	if len(delim) > 0 {
+		return strings.HasPrefix(l.text[l.pos.Offset:], delim)
 	}
 
 	return false
 }
 
-func (l *lexer) tryTokens(nextState stateFn, tokens ...token.Kind) stateFn {
+// longestMatch returns the longest of the given tokens the input starts with,
+// or ILLEGAL if there is none.
+func (l *lexer) longestMatch(tokens ...token.Kind) token.Kind {
+	matched := token.ILLEGAL
+	if l.pos.Offset >= len(l.text) {
+		return matched
+	}
+
+	rest := l.text[l.pos.Offset:]
+
 	for _, t := range tokens {
-		if l.startsWith(t) {
-			return l.lexToken(t, nextState)
+		delim := l.delims.Of(t)
+
+		// Most of the tokens are rejected by the first byte
+		if delim == "" || delim[0] != rest[0] || !strings.HasPrefix(rest, delim) {
+			continue
+		}
+
+		if matched == token.ILLEGAL || len(delim) > len(l.delims.Of(matched)) {
+			matched = t
 		}
 	}
 
-	return nil
+	return matched
+}
+
+// tryTokens lexes the longest of the given tokens the input starts with,
+// so '==' is not lexed as two '=' tokens.
+func (l *lexer) tryTokens(nextState stateFn, tokens ...token.Kind) stateFn {
+	matched := l.longestMatch(tokens...)
+	if matched == token.ILLEGAL {
+		return nil
+	}
+
+	return l.lexToken(matched, nextState)
 }
 
 // TODO: rewrite this whole CRAP
 func (l *lexer) tryKeywords(nextState stateFn) stateFn {
 	a := l.source[l.pos.Offset:]
 
-	for _, tok := range token.GetKeywords() {
+	for _, tok := range keywords {
 		tokBytes := tok.Bytes()
 		if len(tokBytes) > 0 && bytes.HasPrefix(a, tokBytes) {
 			if len(l.source) < l.pos.Offset+len(tokBytes)+1 {
 				return l.lexToken(tok, nextState)
 			}
 
-			if unicode.IsSpace(rune(l.source[l.pos.Offset+len(tokBytes)])) {
+			if r, _ := utf8.DecodeRune(l.source[l.pos.Offset+len(tokBytes):]); unicode.IsSpace(r) {
 				return l.lexToken(tok, nextState)
 			}
 
 			b := l.source[l.pos.Offset+len(tokBytes):]
 
-			for _, tok2 := range token.GetOperators() {
-				tokBytes2 := tok2.Bytes()
-				if len(tokBytes2) > 0 && bytes.HasPrefix(b, tokBytes2) {
+			for _, tok2 := range operators {
+				tokBytes2 := l.delims.Of(tok2)
+				if len(tokBytes2) > 0 && bytes.HasPrefix(b, []byte(tokBytes2)) {
 					return l.lexToken(tok, nextState)
 				}
 			}
@@ -87,10 +156,19 @@ func lexText(l *lexer) stateFn {
 		// 	return lexLineWhitespace(lexText)
 		// }
 
-		if l.startsWith(token.LEXPR) {
+		if !l.delimStarts[l.source[l.pos.Offset]] {
+			l.next()
+
+			continue
+		}
+
+		// Custom delimiters may share a prefix, e.g. '<%=' and '<%'
+		opening := l.longestMatch(token.LEXPR, token.LSTMT, token.LCOMM)
+
+		if opening == token.LEXPR {
 			l.emit(token.TEXT)
 
-			return l.lexToken(token.LEXPR, lexExpr)
+			return l.lexOpening(token.LEXPR, lexExpr)
 		}
 
 		if l.startsWith(token.RARR) {
@@ -99,13 +177,13 @@ func lexText(l *lexer) stateFn {
 			return l.lexToken(token.RARR, lexComm)
 		}
 
-		if l.startsWith(token.LSTMT) {
+		if opening == token.LSTMT {
 			l.emit(token.TEXT)
 
-			return l.lexToken(token.LSTMT, lexStmt)
+			return l.lexOpening(token.LSTMT, lexStmtStart)
 		}
 
-		if l.startsWith(token.LCOMM) {
+		if opening == token.LCOMM {
 			l.emit(token.TEXT)
 
 			return l.lexToken(token.LCOMM, lexComm)
@@ -152,12 +230,8 @@ func lexRealExpr(nextState stateFn) stateFn {
 			return lexLineWhitespace(nextState)
 		}
 
-		if r == '\'' {
-			return lexSQString
-		}
-
-		if r == '"' {
-			return lexDQString
+		if r == token.SQUOTE || r == token.DQUOTE || r == token.BQUOTE {
+			return lexString(r, nextState)
 		}
 
 		if r == token.LPAREN.Rune() {
@@ -172,14 +246,16 @@ func lexRealExpr(nextState stateFn) stateFn {
 			return l.lexToken(token.RPAREN, nextState)
 		}
 
-		if unicode.IsDigit(r) {
+		if '0' <= r && r <= '9' {
 			return lexNum(nextState)
 		}
 
-		if token.IsNotOp(r) && r != token.PERIOD.Rune() {
+		if token.IsIdentStart(r) {
 			return lexIdent(nextState)
 		}
 
+		l.emit(token.ILLEGAL)
+
 		return nextState
 	}
 }
@@ -189,7 +265,11 @@ func lexExpr(l *lexer) stateFn {
 		return l.lexToken(token.REXPR, lexText)
 	}
 
-	if state := l.tryTokens(lexExpr, token.GetOperatorsWithoutKw()...); state != nil {
+	if state := l.tryTrimmedClosing(token.REXPR, lexText); state != nil {
+		return state
+	}
+
+	if state := l.tryTokens(lexExpr, operatorsWithoutKw...); state != nil {
 		return state
 	}
 
@@ -205,8 +285,15 @@ func lexComm(l *lexer) stateFn {
 	for {
 		if l.startsWith(token.RCOMM) {
 			l.emit(token.COMM_TEXT)
+			next := l.lexToken(token.RCOMM, lexLineWhitespace(lexText))
 
-			return l.lexToken(token.RCOMM, lexLineWhitespace(lexText))
+			// The pragma is the first comment, the rest is lexed with its delimiters
+			if l.pragma != nil {
+				l.setDelims(*l.pragma)
+				l.pragma = nil
+			}
+
+			return next
 		}
 
 		r := l.next()
@@ -235,49 +322,86 @@ func lexNum(nextState stateFn) stateFn {
 	}
 }
 
-func lexSQString(l *lexer) stateFn {
-	for {
-		r := l.next()
-		switch r {
-		case eof:
+// lexString lexes a string literal opened by the quote. Backslash escapes
+// the next character, except in raw backtick strings, and double-quoted
+// strings may interpolate '${expr}'. Strings can't span lines.
+func lexString(quote rune, nextState stateFn) stateFn {
+	return func(l *lexer) stateFn {
+		if !l.scanString(quote) {
 			l.emit(token.NOT_TERMINATED_STR)
 
 			return lexText
+		}
 
-		case '\n':
+		l.emit(token.STR)
+
+		return nextState
+	}
+}
+
+// scanString consumes the rest of a string literal up to and including
+// the closing quote. It reports false if the line or the input ends first,
+// leaving the line break unconsumed.
+func (l *lexer) scanString(quote rune) bool {
+	for {
+		switch r := l.next(); {
+		case r == eof:
+			return false
+
+		case r == '\n':
 			l.back()
-			l.emit(token.NOT_TERMINATED_STR)
 
-			return lexText
+			return false
 
-		case token.SQUOTE:
-			l.emit(token.STR)
+		case r == quote:
+			return true
 
-			return lexExpr
+		case r == '\\' && quote != token.BQUOTE:
+			if r := l.peek(); r == '\n' || r == eof {
+				return false
+			}
+
+			l.next()
+
+		case r == '$' && quote == token.DQUOTE && l.peek() == '{':
+			l.next()
+
+			if !l.scanInterpolation() {
+				return false
+			}
 		}
 	}
 }
 
-// TODO: refactor
-func lexDQString(l *lexer) stateFn {
+// scanInterpolation consumes an interpolated expression after '${' up to
+// and including its closing brace, skipping strings nested in it.
+func (l *lexer) scanInterpolation() bool {
+	depth := 0
+
 	for {
-		r := l.next()
-		switch r {
+		switch r := l.next(); r {
 		case eof:
-			l.emit(token.NOT_TERMINATED_STR)
-
-			return lexText
+			return false
 
 		case '\n':
 			l.back()
-			l.emit(token.NOT_TERMINATED_STR)
 
-			return lexText
+			return false
+
+		case token.SQUOTE, token.DQUOTE, token.BQUOTE:
+			if !l.scanString(r) {
+				return false
+			}
 
-		case token.DQUOTE:
-			l.emit(token.STR)
+		case '{':
+			depth++
 
-			return lexExpr
+		case '}':
+			if depth == 0 {
+				return true
+			}
+
+			depth--
 		}
 	}
 }
@@ -285,13 +409,20 @@ func lexDQString(l *lexer) stateFn {
 func lexIdent(nextState stateFn) stateFn {
 	return func(l *lexer) stateFn {
 		for {
+			// Custom closing delimiters may start with characters of identifiers
+			if l.startsWith(token.REXPR) || l.startsWith(token.RSTMT) {
+				l.emit(token.IDENT)
+
+				return nextState
+			}
+
 			switch r := l.next(); {
 			case r == eof:
 				l.emit(token.IDENT)
 
 				return nil
 
-			case !token.IsNotOp(r) || unicode.IsSpace(r):
+			case !token.IsIdentPart(r):
 				l.back()
 				l.emit(token.IDENT)
 
@@ -322,12 +453,108 @@ func lexLineWhitespace(nextState stateFn) stateFn {
 	}
 }
 
+// spaceLen returns the length of spaces and tabs at the start of b.
+func spaceLen(b []byte) int {
+	return len(b) - len(bytes.TrimLeft(b, " \t"))
+}
+
+// closingLen returns the length of the closing delimiter at the start of b
+// with the trim marker preceding it, or 0 if b doesn't start with it.
+func closingLen(b []byte, delim string) int {
+	n := 0
+	if len(b) > 0 && b[0] == token.TrimMarker {
+		n++
+	}
+
+	if !bytes.HasPrefix(b[n:], []byte(delim)) {
+		return 0
+	}
+
+	return n + len(delim)
+}
+
+// lexStmtStart lexes the opening tag of the raw statement, which body is
+// not lexed, or continues lexing of any other statement.
+func lexStmtStart(l *lexer) stateFn {
+	kw := token.RAW.String()
+	rest := l.source[l.pos.Offset:]
+
+	preWs := spaceLen(rest)
+	if !bytes.HasPrefix(rest[preWs:], []byte(kw)) {
+		return lexStmt
+	}
+
+	postWs := spaceLen(rest[preWs+len(kw):])
+	if closingLen(rest[preWs+len(kw)+postWs:], l.delims.RStmt) == 0 {
+		return lexStmt
+	}
+
+	l.skip(preWs)
+	l.emit(token.WS)
+	l.skip(len(kw))
+	l.emit(token.RAW)
+	l.skip(postWs)
+	l.emit(token.WS)
+
+	if state := l.tryTrimmedClosing(token.RSTMT, lexRaw); state != nil {
+		return state
+	}
+
+	return l.lexToken(token.RSTMT, lexRaw)
+}
+
+// indexEndTag returns the index of the first '{% end %}' tag in b, or -1.
+func (l *lexer) indexEndTag(b []byte) int {
+	kw := []byte(token.END.String())
+
+	for i := 0; ; i++ {
+		j := bytes.Index(b[i:], []byte(l.delims.LStmt))
+		if j == -1 {
+			return -1
+		}
+
+		i += j
+		rest := b[i+len(l.delims.LStmt):]
+
+		if len(rest) > 0 && rest[0] == token.TrimMarker {
+			rest = rest[1:]
+		}
+
+		rest = rest[spaceLen(rest):]
+		if !bytes.HasPrefix(rest, kw) {
+			continue
+		}
+
+		rest = rest[len(kw):]
+		if closingLen(rest[spaceLen(rest):], l.delims.RStmt) != 0 {
+			return i
+		}
+	}
+}
+
+// lexRaw lexes the body of the raw statement as is, up to its end tag.
+func lexRaw(l *lexer) stateFn {
+	n := len(l.source) - l.pos.Offset
+	if i := l.indexEndTag(l.source[l.pos.Offset:]); i != -1 {
+		n = i
+	}
+
+	l.skip(n)
+	l.emit(token.RAW_TEXT)
+
+	return lexText
+}
+
 func lexStmt(l *lexer) stateFn {
 	if l.startsWith(token.RSTMT) {
 		return l.lexToken(token.RSTMT, lexLineWhitespace(lexText))
 	}
 
-	if state := l.tryTokens(lexStmt, token.GetOperators()...); state != nil {
+	if state := l.tryTrimmedClosing(token.RSTMT, lexLineWhitespace(lexText)); state != nil {
+		return state
+	}
+
+	if state := l.tryTokens(lexStmt, operators...); state != nil {
 		return state
 	}
 
@@ -0,0 +1,52 @@
+package lexer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// benchSource is a template of about 100 KB with all kinds of tags.
+var benchSource = []byte(strings.Repeat(`{# Service #}
+<h1>{{ service.name -> upper }}</h1>
+{% if service.replicas > 1 && !service.paused %}
+  {% for i, port in service.ports %}
+    <li>{{ i + 1 }}: {{ port.name -> replace("-", "_") }} = {{ port.value }}</li>
+  {% end %}
+{% else %}
+  {{- "single replica" -}}
+{% end %}
+{% raw %}{{ not lexed }}{% end %}
+`, 300))
+
+func BenchmarkTokensFromBytes(b *testing.B) {
+	b.SetBytes(int64(len(benchSource)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		lexer.TokensFromBytes(benchSource)
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	b.SetBytes(int64(len(benchSource)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		s := lexer.NewScanner(benchSource)
+		for s.Next().Kind != token.EOF {
+		}
+	}
+}
+
+func BenchmarkChanFromBytes(b *testing.B) {
+	b.SetBytes(int64(len(benchSource)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for range lexer.ChanFromBytes(benchSource) {
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package lexer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+func TestCustomDelims(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		delims   lexer.Delims
+		expected []token.Token
+	}{
+		{
+			name:   "Expression and statement",
+			input:  "{{ a }}<% if b -%>[[c]]<% end %>",
+			delims: lexer.Delims{LExpr: "[[", RExpr: "]]", LStmt: "<%", RStmt: "%>"},
+			expected: []token.Token{
+				{Kind: token.TEXT, Val: "{{ a }}"},
+				{Kind: token.LSTMT, Val: "<%"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IF},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "b"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "-%>"},
+				{Kind: token.LEXPR, Val: "[["},
+				{Kind: token.IDENT, Val: "c"},
+				{Kind: token.REXPR, Val: "]]"},
+				{Kind: token.LSTMT, Val: "<%"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.END},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "%>"},
+			},
+		},
+		{
+			name:   "Longest opening delimiter",
+			input:  "<%= a %><%# b %>",
+			delims: lexer.Delims{LExpr: "<%=", RExpr: "%>", LStmt: "<%", RStmt: "%>", LComm: "<%#", RComm: "%>"},
+			expected: []token.Token{
+				{Kind: token.LEXPR, Val: "<%="},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR, Val: "%>"},
+				{Kind: token.LCOMM, Val: "<%#"},
+				{Kind: token.COMM_TEXT, Val: " b "},
+				{Kind: token.RCOMM, Val: "%>"},
+			},
+		},
+		{
+			name:   "Closing delimiter after identifier and keyword",
+			input:  "@@a@@$ end$",
+			delims: lexer.Delims{LExpr: "@@", RExpr: "@@", LStmt: "$", RStmt: "$"},
+			expected: []token.Token{
+				{Kind: token.LEXPR, Val: "@@"},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.REXPR, Val: "@@"},
+				{Kind: token.LSTMT, Val: "$"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.END},
+				{Kind: token.RSTMT, Val: "$"},
+			},
+		},
+		{
+			name:   "Raw statement",
+			input:  "[% raw %]{% end %}[[ a ]][% end %]",
+			delims: lexer.Delims{LExpr: "[[", RExpr: "]]", LStmt: "[%", RStmt: "%]"},
+			expected: []token.Token{
+				{Kind: token.LSTMT, Val: "[%"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RAW},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "%]"},
+				{Kind: token.RAW_TEXT, Val: "{% end %}[[ a ]]"},
+				{Kind: token.LSTMT, Val: "[%"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.END},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "%]"},
+			},
+		},
+		{
+			name:  "Pragma",
+			input: "{# flow:delims [[ ]] [% %] [# #] #}\n{{ a }}[[b]]",
+			expected: []token.Token{
+				{Kind: token.LCOMM},
+				{Kind: token.COMM_TEXT, Val: " flow:delims [[ ]] [% %] [# #] "},
+				{Kind: token.RCOMM},
+				{Kind: token.LNBR, Val: "\n"},
+				{Kind: token.TEXT, Val: "{{ a }}"},
+				{Kind: token.LEXPR, Val: "[["},
+				{Kind: token.IDENT, Val: "b"},
+				{Kind: token.REXPR, Val: "]]"},
+			},
+		},
+		{
+			name:   "Pragma written with custom delimiters",
+			input:  "<# flow:delims {{ }} {% %} {# #} #>\n[[ a ]]{{b}}",
+			delims: lexer.Delims{LExpr: "[[", RExpr: "]]", LComm: "<#", RComm: "#>"},
+			expected: []token.Token{
+				{Kind: token.LCOMM, Val: "<#"},
+				{Kind: token.COMM_TEXT, Val: " flow:delims {{ }} {% %} {# #} "},
+				{Kind: token.RCOMM, Val: "#>"},
+				{Kind: token.LNBR, Val: "\n"},
+				{Kind: token.TEXT, Val: "[[ a ]]"},
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "b"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Invalid pragma is a comment",
+			input: "{# flow:delims [[ ]] #}[[b]]",
+			expected: []token.Token{
+				{Kind: token.LCOMM},
+				{Kind: token.COMM_TEXT, Val: " flow:delims [[ ]] "},
+				{Kind: token.RCOMM},
+				{Kind: token.TEXT, Val: "[[b]]"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := lexer.TokensFromBytesWith([]byte(tc.input), tc.delims)
+
+			if err := equal(tokens, tc.expected); err != nil {
+				t.Errorf("%s\nInput: %s\nExpected:\n%v\nGot:\n%v", err, tc.input, tc.expected, tokens)
+			}
+		})
+	}
+}
+
+func TestDelimsValidate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input lexer.Delims
+		valid bool
+	}{
+		{
+			name:  "Default delimiters",
+			valid: true,
+		},
+		{
+			name:  "Opening delimiters with common prefix",
+			input: lexer.Delims{LExpr: "<%=", RExpr: "%>", LStmt: "<%", RStmt: "%>"},
+			valid: true,
+		},
+		{
+			name:  "Same opening delimiters",
+			input: lexer.Delims{LExpr: "{%"},
+		},
+		{
+			name:  "Opening delimiter with trim marker",
+			input: lexer.Delims{LStmt: "<-"},
+		},
+		{
+			name:  "Closing delimiter with trim marker",
+			input: lexer.Delims{RExpr: "->"},
+		},
+		{
+			name:  "Delimiter with whitespace",
+			input: lexer.Delims{LExpr: "{ {"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.input.Validate()
+
+			var delimsErr *lexer.DelimsError
+			if tc.valid && err != nil || !tc.valid && !errors.As(err, &delimsErr) {
+				t.Errorf("Delims: %+v\nUnexpected result: %v", tc.input, err)
+			}
+		})
+	}
+}
+
+func TestPragma(t *testing.T) {
+	d, err := lexer.Pragma([]byte("  {# flow:delims [[ ]] [% %] [# #] #}\n"), lexer.Delims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := lexer.Delims{LExpr: "[[", RExpr: "]]", LStmt: "[%", RStmt: "%]", LComm: "[#", RComm: "#]"}
+	if d != expected {
+		t.Errorf("Expected %+v, got %+v", expected, d)
+	}
+
+	d, err = lexer.Pragma([]byte("x {# flow:delims [[ ]] [% %] [# #] #}"), lexer.Delims{})
+	if err != nil || d != lexer.DefaultDelims {
+		t.Errorf("Pragma must be at the beginning, got %+v, %v", d, err)
+	}
+
+	_, err = lexer.Pragma([]byte("\t{# flow:delims [[ ]] [[ %] [# #] #}"), lexer.Delims{})
+
+	var delimsErr *lexer.DelimsError
+	if !errors.As(err, &delimsErr) || delimsErr.Pos.Column != 2 {
+		t.Errorf("Expected error at column 2, got %v", err)
+	}
+}
@@ -110,33 +110,114 @@ func TestExpressions(t *testing.T) {
 			},
 		},
 		{
-			name:  "Var name with leading dollar sign",
-			input: "{{$name}}",
+			name:  "Var name with non-latin letters",
+			input: "{{こんにちは}}",
 			expected: []token.Token{
 				{Kind: token.LEXPR},
-				{Kind: token.IDENT, Val: "$name"},
+				{Kind: token.IDENT, Val: "こんにちは"},
 				{Kind: token.REXPR},
 			},
 		},
 		{
-			name:  "Var name with dollar sign",
-			input: "{{mirco$oft}}",
+			name:  "Var names with Cyrillic letters and combining marks",
+			input: "{{ имя_2 }}{{ हिन्दी }}{{ a‿b }}",
 			expected: []token.Token{
 				{Kind: token.LEXPR},
-				{Kind: token.IDENT, Val: "mirco$oft"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "имя_2"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "हिन्दी"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a‿b"},
+				{Kind: token.WS, Val: " "},
 				{Kind: token.REXPR},
 			},
 		},
 		{
-			name:  "Var name with non-latin symbols",
-			input: "{{こんにちは}} {{🙋}}",
+			name:  "Currency signs are not identifiers",
+			input: "{{ €x }}{{$name}}{{mirco$oft}}",
 			expected: []token.Token{
 				{Kind: token.LEXPR},
-				{Kind: token.IDENT, Val: "こんにちは"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.ILLEGAL, Val: "€"},
+				{Kind: token.IDENT, Val: "x"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+				{Kind: token.LEXPR},
+				{Kind: token.ILLEGAL, Val: "$"},
+				{Kind: token.IDENT, Val: "name"},
+				{Kind: token.REXPR},
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "mirco"},
+				{Kind: token.ILLEGAL, Val: "$"},
+				{Kind: token.IDENT, Val: "oft"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Emoji are not identifiers",
+			input: "{{ a😀 }}{{🙋}}{{👩‍💻}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.ILLEGAL, Val: "😀"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+				{Kind: token.LEXPR},
+				{Kind: token.ILLEGAL, Val: "🙋"},
 				{Kind: token.REXPR},
-				{Kind: token.TEXT, Val: " "},
 				{Kind: token.LEXPR},
-				{Kind: token.IDENT, Val: "🙋"},
+				{Kind: token.ILLEGAL, Val: "👩"},
+				{Kind: token.ILLEGAL, Val: "‍"},
+				{Kind: token.ILLEGAL, Val: "💻"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Illegal characters",
+			input: "{{ a @ b }}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.ILLEGAL, Val: "@"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "b"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Non-ASCII strings and spaces",
+			input: "{{\u3000'Рабочий стол' }}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: "\u3000"},
+				{Kind: token.STR, Val: "'Рабочий стол'"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "NUL characters in text, tag and string",
+			input: "a\x00b{{ 1\x00 \"\x00\" }}",
+			expected: []token.Token{
+				{Kind: token.TEXT, Val: "a\x00b"},
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.INT, Val: "1"},
+				{Kind: token.ILLEGAL, Val: "\x00"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.STR, Val: "\"\x00\""},
+				{Kind: token.WS, Val: " "},
 				{Kind: token.REXPR},
 			},
 		},
@@ -408,164 +489,163 @@ func TestNumLiterals(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
-// func TestOperations(t *testing.T) {
-// 	testCases := []testCase{
-// 		{
-// 			name:  "Addittion",
-// 			input: "{{seconds+1}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "seconds"},
-// 				{Kind: token.ADD},
-// 				{Kind: token.INT, Val: "1"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Subtraction",
-// 			input: "{{age-123.2}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.SUB},
-// 				{Kind: token.FLOAT, Val: "123.2"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Negative number subtraction",
-// 			input: "{{age- -123.2}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.SUB},
-// 				{Kind: token.WS, Val: " "},
-// 				{Kind: token.SUB},
-// 				{Kind: token.FLOAT, Val: "123.2"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Multiply",
-// 			input: "{{age*30}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.MUL},
-// 				{Kind: token.INT, Val: "30"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Multiply by negative number",
-// 			input: "{{age*-30}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.MUL},
-// 				{Kind: token.SUB},
-// 				{Kind: token.INT, Val: "30"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Multiply by negative number",
-// 			input: "{{age*-30}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.MUL},
-// 				{Kind: token.SUB},
-// 				{Kind: token.INT, Val: "30"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Division",
-// 			input: "{{age/30}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.DIV},
-// 				{Kind: token.INT, Val: "30"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Division by negative number",
-// 			input: "{{age/-30}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.DIV},
-// 				{Kind: token.SUB},
-// 				{Kind: token.INT, Val: "30"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Single parens",
-// 			input: "{{(12/2)+age}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.LPAREN},
-// 				{Kind: token.INT, Val: "12"},
-// 				{Kind: token.DIV},
-// 				{Kind: token.INT, Val: "2"},
-// 				{Kind: token.RPAREN},
-// 				{Kind: token.ADD},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Two operations with parens",
-// 			input: "{{(age/-30)+(12-2.2)}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.LPAREN},
-// 				{Kind: token.IDENT, Val: "age"},
-// 				{Kind: token.DIV},
-// 				{Kind: token.SUB},
-// 				{Kind: token.INT, Val: "30"},
-// 				{Kind: token.RPAREN},
-// 				{Kind: token.ADD},
-// 				{Kind: token.LPAREN},
-// 				{Kind: token.INT, Val: "12"},
-// 				{Kind: token.SUB},
-// 				{Kind: token.FLOAT, Val: "2.2"},
-// 				{Kind: token.RPAREN},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 	}
-// 	runTestCases(t, testCases)
-// }
+func TestOperations(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Addittion",
+			input: "{{seconds+1}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "seconds"},
+				{Kind: token.ADD},
+				{Kind: token.INT, Val: "1"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Subtraction",
+			input: "{{age-123.2}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.MINUS},
+				{Kind: token.FLOAT, Val: "123.2"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Negative number subtraction",
+			input: "{{age- -123.2}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.MINUS},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.MINUS},
+				{Kind: token.FLOAT, Val: "123.2"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Multiply",
+			input: "{{age*30}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.MUL},
+				{Kind: token.INT, Val: "30"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Multiply by negative number",
+			input: "{{age*-30}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.MUL},
+				{Kind: token.MINUS},
+				{Kind: token.INT, Val: "30"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Modulo",
+			input: "{{age%30}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.MOD},
+				{Kind: token.INT, Val: "30"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Division",
+			input: "{{age/30}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.DIV},
+				{Kind: token.INT, Val: "30"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Division by negative number",
+			input: "{{age/-30}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.DIV},
+				{Kind: token.MINUS},
+				{Kind: token.INT, Val: "30"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Single parens",
+			input: "{{(12/2)+age}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.LPAREN},
+				{Kind: token.INT, Val: "12"},
+				{Kind: token.DIV},
+				{Kind: token.INT, Val: "2"},
+				{Kind: token.RPAREN},
+				{Kind: token.ADD},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Two operations with parens",
+			input: "{{(age/-30)+(12-2.2)}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.LPAREN},
+				{Kind: token.IDENT, Val: "age"},
+				{Kind: token.DIV},
+				{Kind: token.MINUS},
+				{Kind: token.INT, Val: "30"},
+				{Kind: token.RPAREN},
+				{Kind: token.ADD},
+				{Kind: token.LPAREN},
+				{Kind: token.INT, Val: "12"},
+				{Kind: token.MINUS},
+				{Kind: token.FLOAT, Val: "2.2"},
+				{Kind: token.RPAREN},
+				{Kind: token.REXPR},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
 
-// func TestOperationsEdgeCases(t *testing.T) {
-// 	testCases := []testCase{
-// 		{
-// 			name:  "Unclosed addition",
-// 			input: "{{1+}}",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.INT, Val: "1"},
-// 				{Kind: token.ADD},
-// 				{Kind: token.REXPR},
-// 			},
-// 		},
-// 		{
-// 			name:  "Unclosed expression with addition",
-// 			input: "{{1+",
-// 			expected: []token.Token{
-// 				{Kind: token.LEXPR},
-// 				{Kind: token.INT, Val: "1"},
-// 				{Kind: token.ADD},
-// 			},
-// 		},
-// 	}
-// 	runTestCases(t, testCases)
-// }
+func TestOperationsEdgeCases(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Unclosed addition",
+			input: "{{1+}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.INT, Val: "1"},
+				{Kind: token.ADD},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Unclosed expression with addition",
+			input: "{{1+",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.INT, Val: "1"},
+				{Kind: token.ADD},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
 
 func TestNumLiteralsEdgeCases(t *testing.T) {
 	testCases := []testCase{
@@ -682,6 +762,79 @@ func TestStringLiteralsEdgeCases(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+func TestStringEscapes(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Escaped quotes",
+			input: `{{"say \"hi\"" 'it\'s'}}`,
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.STR, Val: `"say \"hi\""`},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.STR, Val: `'it\'s'`},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Escaped backslash before quote",
+			input: `{{"a\\" b}}`,
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.STR, Val: `"a\\"`},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "b"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Raw string",
+			input: "{{`C:\\dir\\` }}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.STR, Val: "`C:\\dir\\`"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Interpolation with nested string",
+			input: `{{"a ${ b -> join("}") } c"}}`,
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.STR, Val: `"a ${ b -> join("}") } c"`},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Interpolation in single quotes",
+			input: `{{'${ a }'}}`,
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.STR, Val: `'${ a }'`},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "String interrupted after backslash",
+			input: "{{\"double\\\n",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.NOT_TERMINATED_STR, Val: `"double\`},
+				{Kind: token.LNBR, Val: "\n"},
+			},
+		},
+		{
+			name:  "Unclosed interpolation",
+			input: `{{"${ a"}}`,
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.NOT_TERMINATED_STR, Val: `"${ a"}}`},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func TestFilters(t *testing.T) {
 	testCases := []testCase{
 		{
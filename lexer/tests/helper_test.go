@@ -22,8 +22,9 @@ func equal(gotTokens []token.Token, expectedTokens []token.Token) error {
 			return fmt.Errorf("wrong type: expected %s, got %s", expected.Kind, got.Kind)
 		}
 
+		// Value of delimiters differs from their kind if they have trim markers
 		var expectedValue string
-		if expected.IsValueable() {
+		if expected.IsValueable() || expected.Val != "" {
 			expectedValue = expected.Val
 		} else {
 			expectedValue = expected.Kind.String()
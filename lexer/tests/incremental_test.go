@@ -0,0 +1,115 @@
+package lexer_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+)
+
+func TestRelex(t *testing.T) {
+	source := "Hello\n{{ name -> upper }}\n  {% if a %}\n{# c #}\n{{ b }}{{ c }}\n{% raw %}\n{{ x }}\n{% end %}\n{% end %}"
+
+	testCases := []struct {
+		name   string
+		source string
+		old    string
+		new    string
+	}{
+		{
+			name:   "Expression changed",
+			source: source,
+			old:    "name",
+			new:    "user.name",
+		},
+		{
+			name:   "Lines inserted",
+			source: source,
+			old:    "-> upper",
+			new:    "->\nupper\n",
+		},
+		{
+			name:   "Lines joined",
+			source: source,
+			old:    "}}\n  {%",
+			new:    "}}{%",
+		},
+		{
+			name:   "Next node on the same line",
+			source: source,
+			old:    "{{ b }}",
+			new:    "{{ bb }}",
+		},
+		{
+			name:   "Comment opened",
+			source: source,
+			old:    "{{ name",
+			new:    "{# {{ name",
+		},
+		{
+			name:   "Raw body changed",
+			source: source,
+			old:    "{{ x }}",
+			new:    "{% y %}",
+		},
+		{
+			name:   "Raw end tag removed",
+			source: source,
+			old:    "{% end %}\n{% end %}",
+			new:    "{% end %}",
+		},
+		{
+			name:   "Trim marker added",
+			source: source,
+			old:    "  {% if",
+			new:    "  {%- if",
+		},
+		{
+			name:   "Beginning changed",
+			source: source,
+			old:    "Hello",
+			new:    "{# flow:delims [[ ]] [% %] [# #] #}",
+		},
+		{
+			name:   "Pragma changed",
+			source: "{# flow:delims [[ ]] [% %] [# #] #}\n[[ a ]]\n{{ b }}",
+			old:    "[[ ]]",
+			new:    "{{ }}",
+		},
+		{
+			name:   "Appended",
+			source: source,
+			old:    "{% end %}\n{% end %}",
+			new:    "{% end %}\n{% end %}\n{{ d",
+		},
+		{
+			name:   "Unterminated string",
+			source: "{{ 'a' }}\n{{ b }}",
+			old:    "'a'",
+			new:    "'a",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			edit := lexer.Edit{
+				Offset:  strings.Index(tc.source, tc.old),
+				Deleted: len(tc.old),
+				Text:    tc.new,
+			}
+
+			source := edit.Apply([]byte(tc.source))
+			if expected := strings.Replace(tc.source, tc.old, tc.new, 1); string(source) != expected {
+				t.Fatalf("Apply: expected %q, got %q", expected, source)
+			}
+
+			got := lexer.Relex(lexer.TokensFromBytes([]byte(tc.source)), source, edit)
+			expected := lexer.TokensFromBytes(source)
+
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Source: %q\nExpected: %v\nGot: %v", source, expected, got)
+			}
+		})
+	}
+}
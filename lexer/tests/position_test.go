@@ -16,49 +16,58 @@ func TestPosition(t *testing.T) {
 			input: "{%if name%}\n{%end%}",
 			expected: []token.Token{
 				{Kind: token.LSTMT, Pos: token.Position{
-					Line:   1,
-					Column: 1,
-					Offset: 0,
+					Line:        1,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      0,
 				}},
 				{Kind: token.IF, Pos: token.Position{
-					Line:   1,
-					Column: 3,
-					Offset: 2,
+					Line:        1,
+					Column:      3,
+					UTF16Column: 3,
+					Offset:      2,
 				}},
 				{Kind: token.WS, Val: " ", Pos: token.Position{
-					Line:   1,
-					Column: 5,
-					Offset: 4,
+					Line:        1,
+					Column:      5,
+					UTF16Column: 5,
+					Offset:      4,
 				}},
 				{Kind: token.IDENT, Val: "name", Pos: token.Position{
-					Line:   1,
-					Column: 6,
-					Offset: 5,
+					Line:        1,
+					Column:      6,
+					UTF16Column: 6,
+					Offset:      5,
 				}},
 				{Kind: token.RSTMT, Pos: token.Position{
-					Line:   1,
-					Column: 10,
-					Offset: 9,
+					Line:        1,
+					Column:      10,
+					UTF16Column: 10,
+					Offset:      9,
 				}},
 				{Kind: token.TEXT, Val: "\n", Pos: token.Position{
-					Line:   1,
-					Column: 12,
-					Offset: 11,
+					Line:        1,
+					Column:      12,
+					UTF16Column: 12,
+					Offset:      11,
 				}},
 				{Kind: token.LSTMT, Pos: token.Position{
-					Line:   2,
-					Column: 1,
-					Offset: 12,
+					Line:        2,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      12,
 				}},
 				{Kind: token.END, Pos: token.Position{
-					Line:   2,
-					Column: 3,
-					Offset: 14,
+					Line:        2,
+					Column:      3,
+					UTF16Column: 3,
+					Offset:      14,
 				}},
 				{Kind: token.RSTMT, Pos: token.Position{
-					Line:   2,
-					Column: 6,
-					Offset: 17,
+					Line:        2,
+					Column:      6,
+					UTF16Column: 6,
+					Offset:      17,
 				}},
 			},
 		},
@@ -67,94 +76,190 @@ func TestPosition(t *testing.T) {
 			input: "Hello {{name}}!\nFrom {{ flow }}templates\n\n{{3}}",
 			expected: []token.Token{
 				{Kind: token.TEXT, Val: "Hello ", Pos: token.Position{
-					Line:   1,
-					Column: 1,
-					Offset: 0,
+					Line:        1,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      0,
 				}},
 				{Kind: token.LEXPR, Pos: token.Position{
-					Line:   1,
-					Column: 7,
-					Offset: 6,
+					Line:        1,
+					Column:      7,
+					UTF16Column: 7,
+					Offset:      6,
 				}},
 				{Kind: token.IDENT, Val: "name", Pos: token.Position{
-					Line:   1,
-					Column: 9,
-					Offset: 8,
+					Line:        1,
+					Column:      9,
+					UTF16Column: 9,
+					Offset:      8,
 				}},
 				{Kind: token.REXPR, Pos: token.Position{
-					Line:   1,
-					Column: 13,
-					Offset: 12,
+					Line:        1,
+					Column:      13,
+					UTF16Column: 13,
+					Offset:      12,
 				}},
 				{Kind: token.TEXT, Val: "!", Pos: token.Position{
-					Line:   1,
-					Column: 15,
-					Offset: 14,
+					Line:        1,
+					Column:      15,
+					UTF16Column: 15,
+					Offset:      14,
 				}},
 				{Kind: token.LNBR, Val: "\n", Pos: token.Position{
-					Line:   1,
-					Column: 16,
-					Offset: 15,
+					Line:        1,
+					Column:      16,
+					UTF16Column: 16,
+					Offset:      15,
 				}},
 				{Kind: token.TEXT, Val: "From ", Pos: token.Position{
-					Line:   2,
-					Column: 1,
-					Offset: 16,
+					Line:        2,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      16,
 				}},
 				{Kind: token.LEXPR, Pos: token.Position{
-					Line:   2,
-					Column: 6,
-					Offset: 21,
+					Line:        2,
+					Column:      6,
+					UTF16Column: 6,
+					Offset:      21,
 				}},
 				{Kind: token.WS, Pos: token.Position{
-					Line:   2,
-					Column: 8,
-					Offset: 23,
+					Line:        2,
+					Column:      8,
+					UTF16Column: 8,
+					Offset:      23,
 				}},
 				{Kind: token.IDENT, Val: "flow", Pos: token.Position{
-					Line:   2,
-					Column: 9,
-					Offset: 24,
+					Line:        2,
+					Column:      9,
+					UTF16Column: 9,
+					Offset:      24,
 				}},
 				{Kind: token.WS, Pos: token.Position{
-					Line:   2,
-					Column: 13,
-					Offset: 28,
+					Line:        2,
+					Column:      13,
+					UTF16Column: 13,
+					Offset:      28,
 				}},
 				{Kind: token.REXPR, Pos: token.Position{
-					Line:   2,
-					Column: 14,
-					Offset: 29,
+					Line:        2,
+					Column:      14,
+					UTF16Column: 14,
+					Offset:      29,
 				}},
 				{Kind: token.TEXT, Val: "templates", Pos: token.Position{
-					Line:   2,
-					Column: 16,
-					Offset: 31,
+					Line:        2,
+					Column:      16,
+					UTF16Column: 16,
+					Offset:      31,
 				}},
 				{Kind: token.LNBR, Val: "\n", Pos: token.Position{
-					Line:   2,
-					Column: 25,
-					Offset: 40,
+					Line:        2,
+					Column:      25,
+					UTF16Column: 25,
+					Offset:      40,
 				}},
 				{Kind: token.LNBR, Val: "\n", Pos: token.Position{
-					Line:   3,
-					Column: 1,
-					Offset: 41,
+					Line:        3,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      41,
 				}},
 				{Kind: token.LEXPR, Pos: token.Position{
-					Line:   4,
-					Column: 1,
-					Offset: 42,
+					Line:        4,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      42,
 				}},
 				{Kind: token.INT, Val: "3", Pos: token.Position{
-					Line:   4,
-					Column: 3,
-					Offset: 44,
+					Line:        4,
+					Column:      3,
+					UTF16Column: 3,
+					Offset:      44,
 				}},
 				{Kind: token.REXPR, Pos: token.Position{
-					Line:   4,
-					Column: 4,
-					Offset: 45,
+					Line:        4,
+					Column:      4,
+					UTF16Column: 4,
+					Offset:      45,
+				}},
+			},
+		},
+		{
+			name:  "Non-ASCII text",
+			input: "Привет, {{ имя }}🙋{{ 名前 }}",
+			expected: []token.Token{
+				{Kind: token.TEXT, Val: "Привет, ", Pos: token.Position{
+					Line:        1,
+					Column:      1,
+					UTF16Column: 1,
+					Offset:      0,
+				}},
+				{Kind: token.LEXPR, Pos: token.Position{
+					Line:        1,
+					Column:      9,
+					UTF16Column: 9,
+					Offset:      14,
+				}},
+				{Kind: token.WS, Val: " ", Pos: token.Position{
+					Line:        1,
+					Column:      11,
+					UTF16Column: 11,
+					Offset:      16,
+				}},
+				{Kind: token.IDENT, Val: "имя", Pos: token.Position{
+					Line:        1,
+					Column:      12,
+					UTF16Column: 12,
+					Offset:      17,
+				}},
+				{Kind: token.WS, Val: " ", Pos: token.Position{
+					Line:        1,
+					Column:      15,
+					UTF16Column: 15,
+					Offset:      23,
+				}},
+				{Kind: token.REXPR, Pos: token.Position{
+					Line:        1,
+					Column:      16,
+					UTF16Column: 16,
+					Offset:      24,
+				}},
+				{Kind: token.TEXT, Val: "🙋", Pos: token.Position{
+					Line:        1,
+					Column:      18,
+					UTF16Column: 18,
+					Offset:      26,
+				}},
+				{Kind: token.LEXPR, Pos: token.Position{
+					Line:        1,
+					Column:      19,
+					UTF16Column: 20,
+					Offset:      30,
+				}},
+				{Kind: token.WS, Val: " ", Pos: token.Position{
+					Line:        1,
+					Column:      21,
+					UTF16Column: 22,
+					Offset:      32,
+				}},
+				{Kind: token.IDENT, Val: "名前", Pos: token.Position{
+					Line:        1,
+					Column:      22,
+					UTF16Column: 23,
+					Offset:      33,
+				}},
+				{Kind: token.WS, Val: " ", Pos: token.Position{
+					Line:        1,
+					Column:      24,
+					UTF16Column: 25,
+					Offset:      39,
+				}},
+				{Kind: token.REXPR, Pos: token.Position{
+					Line:        1,
+					Column:      25,
+					UTF16Column: 26,
+					Offset:      40,
 				}},
 			},
 		},
@@ -0,0 +1,56 @@
+package lexer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+func TestScanner(t *testing.T) {
+	s := lexer.NewScanner(benchSource)
+
+	var got []token.Token
+	for tok := s.Next(); tok.Kind != token.EOF; tok = s.Next() {
+		got = append(got, tok)
+	}
+
+	var fromChan []token.Token
+	for tok := range lexer.ChanFromBytes(benchSource) {
+		fromChan = append(fromChan, tok)
+	}
+
+	if !reflect.DeepEqual(got, fromChan) {
+		t.Error("tokens of Scanner and ChanFromBytes differ")
+	}
+
+	// The end is returned repeatedly
+	for range 2 {
+		if tok := s.Next(); tok.Kind != token.EOF || tok.Pos.Offset != len(benchSource) {
+			t.Errorf("expected EOF at %d, got %v", len(benchSource), tok)
+		}
+	}
+}
+
+func TestScannerWithDelims(t *testing.T) {
+	s := lexer.NewScannerWith([]byte("{{ a }}[[ b ]]"), lexer.Delims{LExpr: "[[", RExpr: "]]"})
+
+	expected := []token.Token{
+		{Kind: token.TEXT, Val: "{{ a }}"},
+		{Kind: token.LEXPR, Val: "[["},
+		{Kind: token.WS, Val: " "},
+		{Kind: token.IDENT, Val: "b"},
+		{Kind: token.WS, Val: " "},
+		{Kind: token.REXPR, Val: "]]"},
+	}
+
+	var got []token.Token
+	for tok := s.Next(); tok.Kind != token.EOF; tok = s.Next() {
+		got = append(got, tok)
+	}
+
+	if err := equal(got, expected); err != nil {
+		t.Error(err)
+	}
+}
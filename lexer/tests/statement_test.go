@@ -460,6 +460,143 @@ Text
 	runTestCases(t, testCases)
 }
 
+func TestForStatement(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Simple for statement",
+			input: "{%for item in items%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.FOR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "item"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IN},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "items"},
+				{Kind: token.RSTMT},
+			},
+		},
+		{
+			name:  "For statement with index",
+			input: "{%for i, item in items%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.FOR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "i"},
+				{Kind: token.COMMA},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "item"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IN},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "items"},
+				{Kind: token.RSTMT},
+			},
+		},
+		{
+			name:  "Ident starting with in",
+			input: "{%for item in index%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.FOR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "item"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IN},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "index"},
+				{Kind: token.RSTMT},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestLetStatement(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Simple let statement",
+			input: "{%let a = 1%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.LET},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.ASSIGN},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.INT, Val: "1"},
+				{Kind: token.RSTMT},
+			},
+		},
+		{
+			name:  "Let statement without spaces",
+			input: "{%let a=b==c%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.LET},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.ASSIGN},
+				{Kind: token.IDENT, Val: "b"},
+				{Kind: token.EQL},
+				{Kind: token.IDENT, Val: "c"},
+				{Kind: token.RSTMT},
+			},
+		},
+		{
+			name:  "Let statement with string followed by text",
+			input: `{%let a = "x"%}Hello world`,
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.LET},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "a"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.ASSIGN},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.STR, Val: `"x"`},
+				{Kind: token.RSTMT},
+				{Kind: token.TEXT, Val: "Hello world"},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestInheritanceStatement(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Extend statement",
+			input: `{%extend "base.flow"%}`,
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.EXTEND},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.STR, Val: `"base.flow"`},
+				{Kind: token.RSTMT},
+			},
+		},
+		{
+			name:  "Block statement",
+			input: "{%block body%}{%end%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.BLOCK},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "body"},
+				{Kind: token.RSTMT},
+				{Kind: token.LSTMT},
+				{Kind: token.END},
+				{Kind: token.RSTMT},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func TestStatementEdgeCases(t *testing.T) {
 	testCases := []testCase{
 		{
@@ -505,3 +642,66 @@ func TestStatementEdgeCases(t *testing.T) {
 	}
 	runTestCases(t, testCases)
 }
+
+func TestRawStatement(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Raw statement",
+			input: "{% raw %}{{ a }}{% if %}{% end %}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RAW},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT},
+				{Kind: token.RAW_TEXT, Val: "{{ a }}{% if %}"},
+				{Kind: token.LSTMT},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.END},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT},
+			},
+		},
+		{
+			name:  "Raw statement with line breaks and trim markers",
+			input: "{%-raw-%}\n{#\n{%- end -%}",
+			expected: []token.Token{
+				{Kind: token.LSTMT, Val: "{%-"},
+				{Kind: token.RAW},
+				{Kind: token.RSTMT, Val: "-%}"},
+				{Kind: token.RAW_TEXT, Val: "\n{#\n"},
+				{Kind: token.LSTMT, Val: "{%-"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.END},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "-%}"},
+			},
+		},
+		{
+			name:  "Unclosed raw statement",
+			input: "{% raw %}{{ a }}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RAW},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT},
+				{Kind: token.RAW_TEXT, Val: "{{ a }}"},
+			},
+		},
+		{
+			name:  "Identifier starting with raw",
+			input: "{% if rawValue %}",
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IF},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "rawValue"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
@@ -0,0 +1,93 @@
+package lexer_test
+
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+func TestTrimMarkers(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Statement with trim markers",
+			input: "a {%- if x -%} b",
+			expected: []token.Token{
+				{Kind: token.TEXT, Val: "a "},
+				{Kind: token.LSTMT, Val: "{%-"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IF},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "x"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "-%}"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.TEXT, Val: "b"},
+			},
+		},
+		{
+			name:  "Expression with trim markers",
+			input: "{{- x -}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR, Val: "{{-"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "x"},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.REXPR, Val: "-}}"},
+			},
+		},
+		{
+			name:  "Trim marker without whitespace before closing",
+			input: "{{ x-}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.IDENT, Val: "x"},
+				{Kind: token.REXPR, Val: "-}}"},
+			},
+		},
+		{
+			name:  "Negative number is not a trim marker",
+			input: "{{-1}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.MINUS},
+				{Kind: token.INT, Val: "1"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Trim marker followed by non-ASCII whitespace",
+			input: "{{-\u00a0x}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR, Val: "{{-"},
+				{Kind: token.WS, Val: "\u00a0"},
+				{Kind: token.IDENT, Val: "x"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Minus followed by non-ASCII letter is not a trim marker",
+			input: "{{-\u0445}}",
+			expected: []token.Token{
+				{Kind: token.LEXPR},
+				{Kind: token.MINUS},
+				{Kind: token.IDENT, Val: "\u0445"},
+				{Kind: token.REXPR},
+			},
+		},
+		{
+			name:  "Trim marker after string in statement",
+			input: `{% extend "base" -%}`,
+			expected: []token.Token{
+				{Kind: token.LSTMT},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.EXTEND},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.STR, Val: `"base"`},
+				{Kind: token.WS, Val: " "},
+				{Kind: token.RSTMT, Val: "-%}"},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
@@ -7,11 +7,18 @@ import (
 
 type Ast []Node
 
+// Node is a node of the template: text, comment, expression or statement.
+// Pos and End return positions of its first character and the character
+// right after it, leading whitespace stripped from the node is not included.
 type Node interface {
+	Pos() token.Position
+	End() token.Position
 	node()
 }
 
 type Expr interface {
+	Pos() token.Position
+	End() token.Position
 	expr()
 }
 
@@ -22,19 +29,35 @@ type Stmt interface {
 
 type (
 	NumberLit struct {
-		Pos   token.Position
-		Value value.NumberValue
+		ValuePos token.Position
+		Value    value.NumberValue
+		ValueEnd token.Position
 	}
 
+	// StringLit is a string literal. Raw is spelled as in the source,
+	// quotes included, and Value has its escape sequences resolved.
+	// Text parts of an InterpolatedLit have no quotes, so Quote is zero.
 	StringLit struct {
-		Pos   token.Position
-		Quote byte
-		Value value.StringValue
+		ValuePos token.Position
+		Quote    byte
+		Raw      string
+		Value    value.StringValue
+		ValueEnd token.Position
+	}
+
+	// InterpolatedLit is a double-quoted string literal with '${expr}' in it.
+	// Parts are its text parts as StringLits and interpolated expressions,
+	// in the order they are written.
+	InterpolatedLit struct {
+		ValuePos token.Position
+		Raw      string
+		Parts    []Expr
+		ValueEnd token.Position
 	}
 
 	Ident struct {
-		Pos  token.Position
-		Name string
+		NamePos token.Position
+		Name    string
 	}
 
 	Kw struct {
@@ -67,16 +90,54 @@ type (
 		Rparen token.Position
 	}
 
+	// SelectorExpr is an access to the field of structured value: x.sel
+	SelectorExpr struct {
+		X      Expr
+		Period token.Position
+		Sel    Ident
+	}
+
+	// IndexExpr is an access to the element of list or map: x[index]
+	IndexExpr struct {
+		X      Expr
+		Lbrack token.Position
+		Index  Expr
+		Rbrack token.Position
+	}
+
+	// BadNode is a placeholder for the statement containing syntax errors.
+	BadNode struct {
+		From token.Position
+		To   token.Position
+	}
+
+	// BadExpr is a placeholder for the expression containing syntax errors.
+	BadExpr struct {
+		From token.Position
+		To   token.Position
+	}
+
+	// FilterExpr is an application of the filter: expr -> filter(args).
+	// Args is nil if the filter is used without parentheses.
 	FilterExpr struct {
 		Expr
 		OpPos  token.Position
 		Filter Ident
+		Lparen token.Position
+		Args   []Expr
+		Rparen token.Position
 	}
 
+	// StmtTag holds delimiters of the statement tag. TrimLeft and TrimRight
+	// are set by trim markers: '{%-' and '-%}'. TagEnd is the position right
+	// after the closing delimiter, which length depends on the lexer delimiters.
 	StmtTag struct {
-		PreWs string
-		// LStmt token.Position
-		// RStmt token.Position
+		PreWs     string
+		LStmt     token.Position
+		TrimLeft  bool
+		TrimRight bool
+		RStmt     token.Position
+		TagEnd    token.Position
 	}
 
 	StmtTagWithKw struct {
@@ -86,7 +147,22 @@ type (
 
 	StmtTagWithExpr struct {
 		StmtTag
-		Expr
+		Expr Expr
+	}
+
+	// ForTag is an opening tag of a loop: {% for key, value in expr %}.
+	// Key is optional and holds the index of the current element.
+	ForTag struct {
+		StmtTag
+		Key   *Ident
+		Value Ident
+		In    Kw
+		Expr  Expr
+	}
+
+	BlockTag struct {
+		StmtTag
+		Name Ident
 	}
 
 	Clause struct {
@@ -104,27 +180,76 @@ type (
 type (
 	CommNode struct {
 		PreWs string
-		Pos   token.Position
+		LComm token.Position
 		// TODO: store val without spaces on the sides to format properly
 		Val    string
+		RComm  token.Position
+		TagEnd token.Position
 		PostLB string
 	}
 
+	// TextNode is a text rendered as is. TrimLeft and TrimRight are set
+	// if its leading or trailing whitespace is trimmed by the trim marker
+	// of the adjacent tag, Val keeps the whitespace anyway.
 	TextNode struct {
-		Pos token.Position
-		Val []string
+		ValuePos  token.Position
+		Val       []string
+		ValueEnd  token.Position
+		TrimLeft  bool
+		TrimRight bool
 	}
 
+	// ExprNode is an expression tag. TrimLeft and TrimRight are set
+	// by trim markers: '{{- ' and '-}}'.
 	ExprNode struct {
-		// LBrace token.Position
-		Body Expr
-		// RBrace token.Position
+		LBrace    token.Position
+		TrimLeft  bool
+		Body      Expr
+		TrimRight bool
+		RBrace    token.Position
+		TagEnd    token.Position
 	}
 
+	// GenifNode makes rendering of the whole template skipped
+	// if its condition is false.
 	GenifNode struct {
 		StmtTagWithExpr
 	}
 
+	// LetNode binds value of Expr to Name in the current scope.
+	LetNode struct {
+		StmtTag
+		Name   Ident
+		Assign token.Position
+		Expr   Expr
+	}
+
+	// ExtendNode makes the template a child of the template loaded by Path.
+	// Only blocks of the child template are rendered, in place of the
+	// parent's blocks with the same names.
+	ExtendNode struct {
+		StmtTag
+		Path StringLit
+	}
+
+	// BlockNode is a named section of a template that can be overridden
+	// by templates extending it.
+	BlockNode struct {
+		BlockTag BlockTag
+		Body     []Node
+		EndTag   StmtTag
+	}
+
+	// RawNode is a text rendered as is, tags inside of it are not
+	// interpreted: {% raw %}{{ text }}{% end %}. Val holds the text between
+	// the tags verbatim, including line breaks the tags are on.
+	RawNode struct {
+		RawTag   StmtTag
+		ValuePos token.Position
+		Val      string
+		EndTag   StmtTag
+	}
+
 	IfNode struct {
 		IfTag   StmtTagWithExpr
 		Main    []Node
@@ -139,28 +264,116 @@ type (
 		DefaultCase *Clause
 		EndTag      StmtTag
 	}
+
+	// ForNode renders Body for every element of ForTag.Expr,
+	// or Else if there are no elements.
+	ForNode struct {
+		ForTag ForTag
+		Body   []Node
+		Else   Clause
+		EndTag StmtTag
+	}
 )
 
 func (*CommNode) node()   {}
 func (*TextNode) node()   {}
 func (*ExprNode) node()   {}
 func (*GenifNode) node()  {}
+func (*LetNode) node()    {}
+func (*ExtendNode) node() {}
+func (*BlockNode) node()  {}
+func (*RawNode) node()    {}
 func (*IfNode) node()     {}
 func (*SwitchNode) node() {}
+func (*ForNode) node()    {}
+func (*BadNode) node()    {}
 
 // exprNode() ensures that only expression/type nodes can be
 // assigned to an Expr.
-func (*NumberLit) expr()   {}
-func (*StringLit) expr()   {}
-func (*Ident) expr()       {}
-func (*UnaryExpr) expr()   {}
-func (*BinaryExpr) expr()  {}
-func (*TernaryExpr) expr() {}
-func (*ParenExpr) expr()   {}
-func (*FilterExpr) expr()  {}
+func (*NumberLit) expr()       {}
+func (*StringLit) expr()       {}
+func (*InterpolatedLit) expr() {}
+func (*Ident) expr()           {}
+func (*UnaryExpr) expr()       {}
+func (*BinaryExpr) expr()      {}
+func (*TernaryExpr) expr()     {}
+func (*ParenExpr) expr()       {}
+func (*FilterExpr) expr()      {}
+func (*BadExpr) expr()         {}
+func (*SelectorExpr) expr()    {}
+func (*IndexExpr) expr()       {}
 
 // stmtNode() ensures that only statement nodes can be
 // assigned to a Stmt.
 func (*IfNode) stmt()          {}
+func (*LetNode) stmt()         {}
+func (*ExtendNode) stmt()      {}
+func (*BlockNode) stmt()       {}
+func (*RawNode) stmt()         {}
 func (*StmtTagWithExpr) stmt() {}
 func (*SwitchNode) stmt()      {}
+func (*ForNode) stmt()         {}
+func (*BadNode) stmt()         {}
+
+func (e *NumberLit) Pos() token.Position       { return e.ValuePos }
+func (e *StringLit) Pos() token.Position       { return e.ValuePos }
+func (e *InterpolatedLit) Pos() token.Position { return e.ValuePos }
+func (e *Ident) Pos() token.Position           { return e.NamePos }
+func (e *UnaryExpr) Pos() token.Position       { return e.Op.Pos }
+func (e *BinaryExpr) Pos() token.Position      { return e.X.Pos() }
+func (e *TernaryExpr) Pos() token.Position     { return e.Condition.Pos() }
+func (e *ParenExpr) Pos() token.Position       { return e.Lparen }
+func (e *FilterExpr) Pos() token.Position      { return e.Expr.Pos() }
+func (e *BadExpr) Pos() token.Position         { return e.From }
+func (e *SelectorExpr) Pos() token.Position    { return e.X.Pos() }
+func (e *IndexExpr) Pos() token.Position       { return e.X.Pos() }
+
+func (e *NumberLit) End() token.Position       { return e.ValueEnd }
+func (e *StringLit) End() token.Position       { return e.ValueEnd }
+func (e *InterpolatedLit) End() token.Position { return e.ValueEnd }
+func (e *Ident) End() token.Position           { return e.NamePos.Advance(e.Name) }
+func (e *UnaryExpr) End() token.Position       { return e.Expr.End() }
+func (e *BinaryExpr) End() token.Position      { return e.Y.End() }
+func (e *TernaryExpr) End() token.Position     { return e.FalseExpr.End() }
+func (e *ParenExpr) End() token.Position       { return e.Rparen.Advance(")") }
+func (e *BadExpr) End() token.Position         { return e.To }
+func (e *SelectorExpr) End() token.Position    { return e.Sel.End() }
+func (e *IndexExpr) End() token.Position       { return e.Rbrack.Advance("]") }
+
+func (e *FilterExpr) End() token.Position {
+	if e.Args != nil {
+		return e.Rparen.Advance(")")
+	}
+
+	return e.Filter.End()
+}
+
+func (t StmtTag) end() token.Position {
+	return t.TagEnd
+}
+
+func (n *CommNode) Pos() token.Position   { return n.LComm }
+func (n *TextNode) Pos() token.Position   { return n.ValuePos }
+func (n *ExprNode) Pos() token.Position   { return n.LBrace }
+func (n *GenifNode) Pos() token.Position  { return n.LStmt }
+func (n *LetNode) Pos() token.Position    { return n.LStmt }
+func (n *ExtendNode) Pos() token.Position { return n.LStmt }
+func (n *BlockNode) Pos() token.Position  { return n.BlockTag.LStmt }
+func (n *RawNode) Pos() token.Position    { return n.RawTag.LStmt }
+func (n *IfNode) Pos() token.Position     { return n.IfTag.LStmt }
+func (n *SwitchNode) Pos() token.Position { return n.SwitchTag.LStmt }
+func (n *ForNode) Pos() token.Position    { return n.ForTag.LStmt }
+func (n *BadNode) Pos() token.Position    { return n.From }
+
+func (n *CommNode) End() token.Position   { return n.TagEnd }
+func (n *TextNode) End() token.Position   { return n.ValueEnd }
+func (n *ExprNode) End() token.Position   { return n.TagEnd }
+func (n *GenifNode) End() token.Position  { return n.StmtTag.end() }
+func (n *LetNode) End() token.Position    { return n.StmtTag.end() }
+func (n *ExtendNode) End() token.Position { return n.StmtTag.end() }
+func (n *BlockNode) End() token.Position  { return n.EndTag.end() }
+func (n *RawNode) End() token.Position    { return n.EndTag.end() }
+func (n *IfNode) End() token.Position     { return n.EndTag.end() }
+func (n *SwitchNode) End() token.Position { return n.EndTag.end() }
+func (n *ForNode) End() token.Position    { return n.EndTag.end() }
+func (n *BadNode) End() token.Position    { return n.To }
@@ -13,8 +13,11 @@ const (
 	ErrExpressionExpected ErrorType = "expression expected"
 	// TODO: change message
 	// ErrUnexpectedBeforeStmt ErrorType = "unexpected text before statement tag"
-	ErrEndExpected     ErrorType = "'{% end %}' expected"
-	ErrKeywordExpected ErrorType = "'if', 'genif', 'end' expected"
+	ErrEndExpected      ErrorType = "'{% end %}' expected"
+	ErrKeywordExpected  ErrorType = "'if', 'genif', 'switch', 'for', 'let', 'extend', 'block', 'raw', 'end' expected"
+	ErrInvalidEscape    ErrorType = "invalid escape sequence"
+	ErrInterpolatedPath ErrorType = "path can't be interpolated"
+	ErrInvalidNumber    ErrorType = "invalid number literal"
 )
 
 type Error struct {
@@ -26,6 +29,10 @@ func (e Error) Error() string {
 	return string(e.Typ)
 }
 
+func (e Error) Position() token.Position {
+	return e.Pos
+}
+
 type ExpectedTokensError struct {
 	Pos    token.Position
 	Tokens []token.Kind
@@ -40,35 +47,46 @@ func (e ExpectedTokensError) Error() string {
 	return strings.Join(b, ", ") + " expected"
 }
 
-// type ErrorList []error // nolint: errname
-
-// func (l ErrorList) Error() string {
-// 	switch len(l) {
-// 	case 0:
-// 		return "no errors"
-// 	case 1:
-// 		return l[0].Error()
-// 	}
-
-// 	b := []string{}
-// 	for _, e := range l {
-// 		b = append(b, e.Error())
-// 	}
-
-// 	return strings.Join(b, ", ")
-// }
-
-// // Err returns an error equivalent to this error list.
-// // If the list is empty, Err returns nil.
-// func (l ErrorList) Err() error {
-// 	if len(l) == 0 {
-// 		return nil
-// 	}
-
-// 	return l
-// }
-
-// // Add adds an [Error] with given position and error message to an [ErrorList].
-// func (l *ErrorList) Add(err error) {
-// 	*l = append(*l, err)
-// }
+func (e ExpectedTokensError) Position() token.Position {
+	return e.Pos
+}
+
+// ErrorList is a list of all errors found in a template.
+type ErrorList []error //nolint: errname, recvcheck
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+
+	case 1:
+		return l[0].Error()
+	}
+
+	b := []string{}
+	for _, e := range l {
+		b = append(b, e.Error())
+	}
+
+	return strings.Join(b, ", ")
+}
+
+// Unwrap returns errors of the list, so each of them can be inspected.
+func (l ErrorList) Unwrap() []error {
+	return l
+}
+
+// Err returns an error equivalent to this error list.
+// If the list is empty, Err returns nil.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+
+	return l
+}
+
+// Add adds an error to an [ErrorList].
+func (l *ErrorList) Add(err error) {
+	*l = append(*l, err)
+}
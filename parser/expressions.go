@@ -8,24 +8,23 @@ import (
 )
 
 func (p *parser) parseExprNode() (*ExprNode, error) {
-	var exprNode ExprNode
+	exprNode := ExprNode{
+		LBrace:   p.currentToken.Pos,
+		TrimLeft: p.currentToken.HasTrimMarker(),
+	}
 
 	p.next() // Consume LEXPR
 	p.consumeWhitespace()
 
-	body, err := p.parseExpr()
+	body, err := p.parseTagExpr(token.REXPR)
 	if err != nil {
 		return nil, err
 	}
 
 	exprNode.Body = body
-
-	if p.currentToken.Kind != token.REXPR {
-		return nil, ExpectedTokensError{
-			Pos:    p.currentToken.Pos,
-			Tokens: []token.Kind{token.REXPR},
-		}
-	}
+	exprNode.TrimRight = p.currentToken.HasTrimMarker()
+	exprNode.RBrace = p.currentToken.Pos
+	exprNode.TagEnd = p.currentToken.End()
 
 	p.next() // Consume REXPR
 
@@ -56,23 +55,68 @@ func (p *parser) parseFilterExpr() (Expr, error) {
 		}
 
 		ident := Ident{
-			Pos:  p.currentToken.Pos,
-			Name: p.currentToken.Val,
+			NamePos: p.currentToken.Pos,
+			Name:    p.currentToken.Val,
 		}
 
 		p.next()
 		p.consumeWhitespace()
 
-		expr = &FilterExpr{
+		filter := FilterExpr{
 			Expr:   expr,
 			OpPos:  opPos,
 			Filter: ident,
 		}
+
+		if p.currentToken.Kind == token.LPAREN {
+			if err := p.parseFilterArgs(&filter); err != nil {
+				return nil, err
+			}
+		}
+
+		expr = &filter
 	}
 
 	return expr, nil
 }
 
+// parseFilterArgs parses comma separated arguments of the filter in parentheses.
+func (p *parser) parseFilterArgs(filter *FilterExpr) error {
+	filter.Lparen = p.currentToken.Pos
+	filter.Args = []Expr{}
+
+	p.next() // Consume '('
+	p.consumeWhitespace()
+
+	for p.currentToken.Kind != token.RPAREN {
+		if len(filter.Args) > 0 {
+			if p.currentToken.Kind != token.COMMA {
+				return ExpectedTokensError{
+					Pos:    p.currentToken.Pos,
+					Tokens: []token.Kind{token.COMMA, token.RPAREN},
+				}
+			}
+
+			p.next() // Consume ','
+			p.consumeWhitespace()
+		}
+
+		arg, err := p.parseExpr()
+		if err != nil {
+			return err
+		}
+
+		filter.Args = append(filter.Args, arg)
+	}
+
+	filter.Rparen = p.currentToken.Pos
+
+	p.next() // Consume ')'
+	p.consumeWhitespace()
+
+	return nil
+}
+
 func (p *parser) parseTernaryExpr(minPrecedence int) (Expr, error) {
 	condition, err := p.parseBinaryExpr(minPrecedence)
 	if err != nil {
@@ -135,7 +179,11 @@ func (p *parser) parseTernaryExpr(minPrecedence int) (Expr, error) {
 }
 
 func (p *parser) parseUnaryExpr() (Expr, error) {
-	if p.currentToken.IsOneOfMany(token.NOT, token.EXCL) {
+	// Negative number literals are handled by parsePrimary
+	isNegation := p.currentToken.Kind == token.MINUS &&
+		!p.checkNextNTokens(token.INT) && !p.checkNextNTokens(token.FLOAT)
+
+	if isNegation || p.currentToken.IsOneOfMany(token.NOT, token.EXCL) {
 		op := p.currentToken
 
 		p.next() // Consume operator
@@ -155,7 +203,78 @@ func (p *parser) parseUnaryExpr() (Expr, error) {
 		}, nil
 	}
 
-	return p.parsePrimary()
+	return p.parsePostfixExpr()
+}
+
+// parsePostfixExpr handles member access and indexing of primary expressions.
+func (p *parser) parsePostfixExpr() (Expr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.currentToken.Kind {
+		case token.PERIOD:
+			selector := SelectorExpr{
+				X:      expr,
+				Period: p.currentToken.Pos,
+			}
+
+			p.next() // Consume '.'
+
+			// Keywords are allowed as field names, e.g. item.default
+			if p.currentToken.Kind != token.IDENT && !p.currentToken.IsKeyword() {
+				return nil, ExpectedTokensError{
+					Pos:    p.currentToken.Pos,
+					Tokens: []token.Kind{token.IDENT},
+				}
+			}
+
+			selector.Sel = Ident{
+				NamePos: p.currentToken.Pos,
+				Name:    p.currentToken.Val,
+			}
+
+			p.next()
+			p.consumeWhitespace()
+
+			expr = &selector
+
+		case token.LBRACK:
+			index := IndexExpr{
+				X:      expr,
+				Lbrack: p.currentToken.Pos,
+			}
+
+			p.next() // Consume '['
+			p.consumeWhitespace()
+
+			indexExpr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			index.Index = indexExpr
+
+			if p.currentToken.Kind != token.RBRACK {
+				return nil, ExpectedTokensError{
+					Pos:    p.currentToken.Pos,
+					Tokens: []token.Kind{token.RBRACK},
+				}
+			}
+
+			index.Rbrack = p.currentToken.Pos
+
+			p.next() // Consume ']'
+			p.consumeWhitespace()
+
+			expr = &index
+
+		default:
+			return expr, nil
+		}
+	}
 }
 
 func (p *parser) parseBinaryExpr(minPrecedence int) (Expr, error) {
@@ -214,8 +333,8 @@ func (p *parser) parsePrimary() (Expr, error) {
 	switch p.currentToken.Kind {
 	case token.IDENT:
 		ident := Ident{
-			Pos:  p.currentToken.Pos,
-			Name: p.currentToken.Val,
+			NamePos: p.currentToken.Pos,
+			Name:    p.currentToken.Val,
 		}
 
 		p.next()
@@ -224,59 +343,53 @@ func (p *parser) parsePrimary() (Expr, error) {
 		return &ident, nil
 
 	case token.STR:
-		lit := &StringLit{
-			Pos:   p.currentToken.Pos,
-			Quote: p.currentToken.Val[0],
-			Value: value.StringValue(p.currentToken.Val[1 : len(p.currentToken.Val)-1]),
-		}
-
-		p.next()
-		p.consumeWhitespace()
-
-		return lit, nil
+		return p.parseString()
 
 	case token.MINUS, token.INT, token.FLOAT:
 		var negative bool
 
+		pos := p.currentToken.Pos
+
 		if p.currentToken.Kind == token.MINUS {
 			p.next()
 
 			negative = true
 		}
 
-		var lit Expr
-
 		//nolint: exhaustive
 		switch p.currentToken.Kind {
 		case token.INT, token.FLOAT:
 			v, err := strconv.ParseFloat(p.currentToken.Val, 64)
 			if err != nil {
-				panic(err) // TODO: replace
+				return nil, Error{
+					Pos: p.currentToken.Pos,
+					Typ: ErrInvalidNumber,
+				}
 			}
 
 			if negative {
 				v *= -1
 			}
 
-			lit = &NumberLit{
-				Pos:   p.currentToken.Pos,
-				Value: value.NumberValue(v),
+			lit := &NumberLit{
+				ValuePos: pos,
+				Value:    value.NumberValue(v),
+				ValueEnd: p.currentToken.Pos.Advance(p.currentToken.Val),
 			}
 
-		case token.STR:
-			quote := p.currentToken.Val[0]
+			p.next()
+			p.consumeWhitespace()
 
-			lit = &StringLit{
-				Pos:   p.currentToken.Pos,
-				Quote: quote,
-				Value: value.StringValue(p.currentToken.Val[1 : len(p.currentToken.Val)-1]),
-			}
-		}
+			return lit, nil
 
-		p.next()
-		p.consumeWhitespace()
+		case token.STR:
+			return p.parseString()
+		}
 
-		return lit, nil
+		return nil, Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrExpressionExpected,
+		}
 
 	case token.LPAREN:
 		parenExpr := ParenExpr{
@@ -330,12 +443,14 @@ func getPrecedence(tok token.Token) (int, bool) {
 
 	case token.AND, token.LAND:
 		return 20, false
-	// case token.ADD, token.SUB:
-	// 	return 20, false // Left-associative
-	// case token.MUL, token.DIV:
-	// 	return 30, false // Left-associative
+
+	case token.ADD, token.MINUS:
+		return 30, false
+
+	case token.MUL, token.DIV, token.MOD:
+		return 40, false
 	// case token.POW:
-	// 	return 40, true
+	// 	return 50, true
 
 	default:
 		return 0, false
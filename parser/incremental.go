@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+func Reparse(ast Ast, tokens []token.Token, source []byte, edit lexer.Edit) (Ast, []token.Token, error) {
+	return ReparseWith(ast, tokens, source, edit, lexer.DefaultDelims)
+}
+
+// ReparseWith parses the source changed by the edit, given the AST and tokens
+// of the source before it. The AST must be parsed without errors, nil may be
+// passed otherwise. Only top-level nodes around the edit are parsed again:
+// nodes before it are reused as is, nodes starting the lines after it are
+// moved in place with the rest of the AST, so the old AST must not be used
+// after the call. Tokens of the changed source
+// are returned for the next edit.
+func ReparseWith(
+	ast Ast,
+	tokens []token.Token,
+	source []byte,
+	edit lexer.Edit,
+	delims lexer.Delims,
+) (Ast, []token.Token, error) {
+	if err := delims.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	newTokens := lexer.RelexWith(tokens, source, edit, delims)
+
+	if len(ast) == 0 || edit.Offset <= lexer.PragmaEnd(tokens) {
+		ast, err := parseTokens(source, newTokens, delims)
+
+		return ast, newTokens, err
+	}
+
+	starts := make([]int, len(ast))
+	for i, node := range ast {
+		starts[i] = nodeStart(node)
+	}
+
+	// The node the edit is in, text before it is parsed again too,
+	// as it is trimmed by the node's trim marker
+	r := max(sort.SearchInts(starts, edit.Offset)-1, 0)
+	if r > 0 && isText(ast[r-1]) {
+		r--
+	}
+
+	first := 0
+	if r != 0 {
+		first = sort.Search(len(newTokens), func(i int) bool {
+			return newTokens[i].Pos.Offset >= starts[r]
+		})
+
+		// Tokens before the edit are changed by the lexer lookahead
+		if first == len(newTokens) || newTokens[first].Pos.Offset != starts[r] {
+			ast, err := parseTokens(source, newTokens, delims)
+
+			return ast, newTokens, err
+		}
+	}
+
+	p := newParser(newTokens)
+	p.seek(first)
+
+	nodes := slices.Clone(ast[:r])
+	insertedEnd := edit.Offset + len(edit.Text)
+	delta := edit.Delta()
+
+	for p.pos < len(p.tokens) {
+		offset := p.currentToken.Pos.Offset
+
+		if offset >= insertedEnd {
+			j := sort.SearchInts(starts, offset-delta)
+
+			// Text is trimmed by the preceding node, so it is always parsed.
+			// Tokens of the rest of the line may be changed by the edit and
+			// leading whitespace is a part of the node only at the line start,
+			// so only nodes starting the same line as before are reused
+			if j < len(ast) && starts[j] == offset-delta && !isText(ast[j]) &&
+				startsLine(newTokens, offset) && startsLine(tokens, starts[j]) &&
+				leadingWs(ast[j]) == wsValue(p.currentToken) {
+				old := ast[j].Pos()
+				pos := p.currentToken.Pos.Advance(leadingWs(ast[j]))
+
+				s := shifter{
+					line:       old.Line,
+					delta:      delta,
+					lineDelta:  pos.Line - old.Line,
+					colDelta:   pos.Column - old.Column,
+					col16Delta: pos.UTF16Column - old.UTF16Column,
+				}
+
+				for _, node := range ast[j:] {
+					s.apply(reflect.ValueOf(node))
+				}
+
+				return append(nodes, ast[j:]...), newTokens, p.errs.Err()
+			}
+		}
+
+		nodes = p.parseTopLevel(nodes)
+	}
+
+	return nodes, newTokens, p.errs.Err()
+}
+
+// seek moves the parser to the token i.
+func (p *parser) seek(i int) {
+	p.pos = i
+	p.currentToken = p.getCurrent()
+}
+
+// leadingWs returns the whitespace before the node that is a part of it.
+func leadingWs(node Node) string {
+	switch n := node.(type) {
+	case *CommNode:
+		return n.PreWs
+	case *GenifNode:
+		return n.PreWs
+	case *LetNode:
+		return n.PreWs
+	case *ExtendNode:
+		return n.PreWs
+	case *BlockNode:
+		return n.BlockTag.PreWs
+	case *RawNode:
+		return n.RawTag.PreWs
+	case *IfNode:
+		return n.IfTag.PreWs
+	case *SwitchNode:
+		return n.SwitchTag.PreWs
+	case *ForNode:
+		return n.ForTag.PreWs
+	default:
+		return ""
+	}
+}
+
+// startsLine reports whether the token at the offset is the first one
+// on its line.
+func startsLine(tokens []token.Token, offset int) bool {
+	i := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Pos.Offset >= offset
+	})
+
+	return i == 0 || tokens[i-1].Kind == token.LNBR
+}
+
+func wsValue(tok token.Token) string {
+	if tok.Kind != token.WS {
+		return ""
+	}
+
+	return tok.Val
+}
+
+func isText(node Node) bool {
+	_, ok := node.(*TextNode)
+
+	return ok
+}
+
+// nodeStart returns the offset of the first token of the node.
+func nodeStart(node Node) int {
+	return node.Pos().Offset - len(leadingWs(node))
+}
+
+var positionType = reflect.TypeFor[token.Position]()
+
+// shifter moves positions of the nodes after the edit. Columns are changed
+// only on the line the edit ends on.
+type shifter struct {
+	line       int
+	delta      int
+	lineDelta  int
+	colDelta   int
+	col16Delta int
+}
+
+func (s shifter) apply(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			s.apply(v.Elem())
+		}
+
+	case reflect.Slice:
+		for i := range v.Len() {
+			s.apply(v.Index(i))
+		}
+
+	case reflect.Struct:
+		if v.Type() == positionType {
+			s.shift(v.Addr().Interface().(*token.Position)) //nolint: forcetypeassert
+
+			return
+		}
+
+		if v.Type().PkgPath() != nodeType.PkgPath() {
+			return
+		}
+
+		for i := range v.NumField() {
+			s.apply(v.Field(i))
+		}
+	}
+}
+
+func (s shifter) shift(pos *token.Position) {
+	// Positions of missing parts are not set
+	if pos.Line == 0 {
+		return
+	}
+
+	if pos.Line == s.line {
+		pos.Column += s.colDelta
+		pos.UTF16Column += s.col16Delta
+	}
+
+	pos.Line += s.lineDelta
+	pos.Offset += s.delta
+}
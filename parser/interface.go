@@ -2,17 +2,38 @@ package parser
 
 import (
 	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/token"
 )
 
 func AstFromBytes(input []byte) (Ast, error) {
-	tokens := lexer.TokensFromBytes(input)
+	return AstFromBytesWith(input, lexer.DefaultDelims)
+}
 
-	ast, err := newParser(tokens).parse()
-	if err != nil {
+// AstFromBytesWith parses the template written with the given delimiters,
+// unless they are changed by the pragma at its beginning. Invalid delimiters
+// or pragma are reported as *lexer.DelimsError.
+func AstFromBytesWith(input []byte, delims lexer.Delims) (Ast, error) {
+	if err := delims.Validate(); err != nil {
 		return nil, err
 	}
 
-	return ast, nil
+	return parseTokens(input, lexer.TokensFromBytesWith(input, delims), delims)
+}
+
+// parseTokens parses tokens of the input lexed with the delimiters.
+func parseTokens(input []byte, tokens []token.Token, delims lexer.Delims) (Ast, error) {
+	var errs ErrorList
+
+	// On invalid pragma its comment is parsed as a regular one
+	if _, err := lexer.Pragma(input, delims); err != nil {
+		errs.Add(err)
+	}
+
+	// On errors AST is partial, nodes that can't be parsed are omitted
+	p := newParser(tokens)
+	p.errs = errs
+
+	return p.parse()
 }
 
 // func ChanFromString(input string) <-chan Node {
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"errors"
 	"strings"
 	"unicode"
 
@@ -12,6 +11,16 @@ type parser struct {
 	tokens       []token.Token
 	pos          int
 	currentToken token.Token
+	// Errors parser has recovered from
+	errs ErrorList
+	// Positions of the last consumed "{%" and "%}"
+	lstmt token.Position
+	rstmt token.Position
+	// End of the last consumed "%}"
+	rstmtEnd token.Position
+	// Trim markers of the last consumed "{%" and "%}"
+	ltrim bool
+	rtrim bool
 }
 
 func newParser(tokens []token.Token) *parser {
@@ -29,19 +38,91 @@ func (p *parser) parse() (Ast, error) {
 	var nodes []Node
 
 	for p.pos < len(p.tokens) {
-		node, err := p.parseNode()
-		if err != nil {
-			return nil, err
-		}
+		nodes = p.parseTopLevel(nodes)
+	}
+
+	return nodes, p.errs.Err()
+}
 
-		if node != nil {
-			nodes = append(nodes, node)
-		} else {
+// parseTopLevel parses the next top-level node and appends it to the nodes,
+// on errors the node is skipped.
+func (p *parser) parseTopLevel(nodes Ast) Ast {
+	start := p.pos
+
+	node, err := p.parseNode()
+	if err != nil {
+		p.resync(start, err)
+
+		return nodes
+	}
+
+	if node != nil {
+		return append(nodes, node)
+	}
+
+	p.next()
+
+	return nodes
+}
+
+// resync records the error of the node started at the start position and
+// skips tokens up to the beginning of the next tag, or right after the end
+// of the current one, so parsing can be resumed.
+func (p *parser) resync(start int, err error) {
+	p.errs.Add(err)
+
+	for p.pos < len(p.tokens) {
+		switch p.currentToken.Kind { //nolint: exhaustive
+		case token.LSTMT, token.LEXPR, token.LCOMM:
+			if p.pos == start {
+				break // Node failed without consuming anything
+			}
+
+			return
+
+		case token.RSTMT, token.REXPR, token.RCOMM:
 			p.next()
+
+			return
+		}
+
+		p.next()
+	}
+}
+
+// parseTagExpr parses expression that must be followed by the closing token
+// of the tag. Errors inside of the tag are recorded and skipped up to the
+// closing token with expression replaced by [BadExpr]. Error is returned only
+// if the closing token is missing, so the tag can't be recovered.
+func (p *parser) parseTagExpr(closing token.Kind) (Expr, error) {
+	from := p.currentToken.Pos
+
+	expr, err := p.parseExpr()
+	if err == nil {
+		if p.currentToken.Kind == closing {
+			return expr, nil
+		}
+
+		err = ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{closing},
 		}
 	}
 
-	return nodes, nil
+	for p.currentToken.Kind != closing {
+		if p.pos >= len(p.tokens) || p.currentToken.IsOneOfMany(token.LSTMT, token.LEXPR, token.LCOMM) {
+			return nil, err
+		}
+
+		p.next()
+	}
+
+	p.errs.Add(err)
+
+	return &BadExpr{
+		From: from,
+		To:   p.currentToken.Pos,
+	}, nil
 }
 
 func (p *parser) getCurrent() token.Token {
@@ -53,10 +134,33 @@ func (p *parser) getCurrent() token.Token {
 }
 
 func (p *parser) next() {
+	switch p.currentToken.Kind { //nolint: exhaustive
+	case token.LSTMT:
+		p.lstmt = p.currentToken.Pos
+		p.ltrim = p.currentToken.HasTrimMarker()
+
+	case token.RSTMT:
+		p.rstmt = p.currentToken.Pos
+		p.rstmtEnd = p.currentToken.End()
+		p.rtrim = p.currentToken.HasTrimMarker()
+	}
+
 	p.pos++
 	p.currentToken = p.getCurrent()
 }
 
+// stmtTag returns the tag that has just been consumed.
+func (p *parser) stmtTag(preWs string) StmtTag {
+	return StmtTag{
+		PreWs:     preWs,
+		LStmt:     p.lstmt,
+		TrimLeft:  p.ltrim,
+		TrimRight: p.rtrim,
+		RStmt:     p.rstmt,
+		TagEnd:    p.rstmtEnd,
+	}
+}
+
 func (p *parser) consumeToken(t token.Kind) string {
 	var val string
 	if p.currentToken.Kind == t {
@@ -141,6 +245,9 @@ func (p *parser) parseNode() (Node, error) {
 func (p *parser) parseText() *TextNode {
 	var res []string
 
+	pos := p.currentToken.Pos
+	trimLeft := p.trimmedBy(p.pos-1, -1)
+
 	for p.currentToken.IsOneOfMany(token.TEXT, token.LNBR, token.WS) {
 		if p.currentToken.Kind == token.WS && p.checkNextNTokens(token.LSTMT) {
 			break
@@ -152,13 +259,38 @@ func (p *parser) parseText() *TextNode {
 	}
 
 	text := TextNode{
-		Pos: p.currentToken.Pos,
-		Val: res,
+		ValuePos:  pos,
+		Val:       res,
+		ValueEnd:  p.currentToken.Pos,
+		TrimLeft:  trimLeft,
+		TrimRight: p.trimmedBy(p.pos, 1),
 	}
 
 	return &text
 }
 
+// trimmedBy reports whether the text is adjacent to a tag with the trim
+// marker on its side. The tag is looked for from the token i in the
+// direction dir, skipping whitespace the parser drops around tags.
+func (p *parser) trimmedBy(i, dir int) bool {
+	for ; i >= 0 && i < len(p.tokens); i += dir {
+		tok := p.tokens[i]
+
+		switch {
+		case tok.IsOneOfMany(token.WS, token.LNBR):
+			continue
+
+		case dir < 0:
+			return tok.IsOneOfMany(token.RSTMT, token.REXPR) && tok.HasTrimMarker()
+
+		default:
+			return tok.IsOneOfMany(token.LSTMT, token.LEXPR) && tok.HasTrimMarker()
+		}
+	}
+
+	return false
+}
+
 func trimSpaces(s string) string {
 	return strings.TrimFunc(s, func(r rune) bool {
 		return unicode.IsSpace(r) && r != '\n' && r != '\r'
@@ -167,8 +299,8 @@ func trimSpaces(s string) string {
 
 func (p *parser) parseComm(preWs string) (*CommNode, error) {
 	commNode := CommNode{
-		Pos:   p.currentToken.Pos,
 		PreWs: preWs,
+		LComm: p.currentToken.Pos,
 	}
 
 	p.next() // Consume LCOMM
@@ -181,7 +313,10 @@ func (p *parser) parseComm(preWs string) (*CommNode, error) {
 
 	case token.RCOMM:
 	default:
-		return nil, errors.New("unexpected token inside comment")
+		return nil, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.RCOMM},
+		}
 	}
 
 	if p.currentToken.Kind != token.RCOMM {
@@ -191,7 +326,10 @@ func (p *parser) parseComm(preWs string) (*CommNode, error) {
 		}
 	}
 
-	p.next()
+	commNode.RComm = p.currentToken.Pos
+	commNode.TagEnd = p.currentToken.End()
+
+	p.next() // Consume RCOMM
 	p.consumeWhitespace()
 
 	commNode.PostLB = p.consumeLineBreak()
@@ -207,19 +345,110 @@ func (p *parser) parseStmt(preWs string) (Node, error) {
 		}
 	}
 
+	from := p.currentToken.Pos
+
 	p.next() // Consume LSTMT
 	p.consumeWhitespace()
 
+	kind := p.currentToken.Kind
+	tagEnd := p.findTagEnd()
+
+	node, err := p.parseStmtByKeyword(preWs)
+	if err == nil || !isBlockStmt(kind) || tagEnd < p.pos {
+		return node, err
+	}
+
+	// Opening tag of the block statement is broken, its body is skipped
+	// so the end tag is not reported as unexpected
+	p.errs.Add(err)
+	p.pos = tagEnd
+	p.next() // Consume RSTMT
+	p.skipBlock()
+
+	return &BadNode{
+		From: from,
+		To:   p.currentToken.Pos,
+	}, nil
+}
+
+func isBlockStmt(kind token.Kind) bool {
+	return kind == token.IF || kind == token.SWITCH || kind == token.FOR || kind == token.BLOCK || kind == token.RAW
+}
+
+// findTagEnd returns index of the closing token of the current tag, or -1.
+func (p *parser) findTagEnd() int {
+	for i := p.pos; i < len(p.tokens); i++ {
+		switch p.tokens[i].Kind { //nolint: exhaustive
+		case token.RSTMT:
+			return i
+
+		case token.LSTMT, token.LEXPR, token.LCOMM:
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// skipBlock skips nodes and intermediate tags of the block statement
+// up to and including its end tag.
+func (p *parser) skipBlock() {
+	for {
+		p.parseBody() //nolint: errcheck
+		p.consumeWhitespace()
+
+		if p.currentToken.Kind != token.LSTMT {
+			return
+		}
+
+		start := p.pos
+
+		p.next() // Consume LSTMT
+		p.consumeWhitespace()
+
+		if p.currentToken.Kind == token.END {
+			if err := p.consumeEndTag(); err != nil {
+				p.resync(start, err)
+			}
+
+			return
+		}
+
+		// Skip else, case or default tag
+		for p.pos < len(p.tokens) && p.currentToken.Kind != token.RSTMT {
+			p.next()
+		}
+
+		p.next() // Consume RSTMT
+	}
+}
+
+func (p *parser) parseStmtByKeyword(preWs string) (Node, error) {
 	switch p.currentToken.Kind {
 	case token.IF:
 		return p.parseIfStmt(preWs)
 
 	case token.GENIF:
-		return p.parseGenIfStmt()
+		return p.parseGenIfStmt(preWs)
 
 	case token.SWITCH:
 		return p.parseSwitchStmt(preWs)
 
+	case token.FOR:
+		return p.parseForStmt(preWs)
+
+	case token.LET:
+		return p.parseLetStmt(preWs)
+
+	case token.EXTEND:
+		return p.parseExtendStmt(preWs)
+
+	case token.BLOCK:
+		return p.parseBlockStmt(preWs)
+
+	case token.RAW:
+		return p.parseRawStmt(preWs)
+
 	default:
 		return nil, Error{
 			Pos: p.currentToken.Pos,
@@ -268,7 +497,7 @@ func (p *parser) parseElses(ifStmt *IfNode) error {
 				return err
 			}
 
-			ifStmt.EndTag = StmtTag{PreWs: preTagWs}
+			ifStmt.EndTag = p.stmtTag(preTagWs)
 
 			return nil
 
@@ -298,6 +527,8 @@ func (p *parser) parseElses(ifStmt *IfNode) error {
 
 			p.next() // Consume RSTMT
 
+			elseTag := p.stmtTag(preTagWs)
+
 			p.consumeLineBreak()
 
 			elseBody, err := p.parseBody()
@@ -306,7 +537,7 @@ func (p *parser) parseElses(ifStmt *IfNode) error {
 			}
 
 			ifStmt.Else = Clause{
-				Tag:  StmtTag{PreWs: preTagWs},
+				Tag:  elseTag,
 				Body: elseBody,
 			}
 
@@ -326,7 +557,7 @@ func (p *parser) parseElses(ifStmt *IfNode) error {
 				return err
 			}
 
-			ifStmt.EndTag = StmtTag{PreWs: preEndTagWs}
+			ifStmt.EndTag = p.stmtTag(preEndTagWs)
 
 			return nil
 
@@ -343,20 +574,15 @@ func (p *parser) parseElseIf(preTagWs string) (ClauseWithExpr, error) {
 	p.next() // Consume IF
 	p.consumeWhitespace()
 
-	expr, err := p.parseExpr()
+	expr, err := p.parseTagExpr(token.RSTMT)
 	if err != nil {
 		return ClauseWithExpr{}, err
 	}
 
-	if p.currentToken.Kind != token.RSTMT {
-		return ClauseWithExpr{}, ExpectedTokensError{
-			Pos:    p.currentToken.Pos,
-			Tokens: []token.Kind{token.RSTMT},
-		}
-	}
-
 	p.next() // Consume RSTMT
 
+	tag := p.stmtTag(preTagWs)
+
 	p.consumeLineBreak()
 
 	body, err := p.parseBody()
@@ -366,43 +592,30 @@ func (p *parser) parseElseIf(preTagWs string) (ClauseWithExpr, error) {
 
 	return ClauseWithExpr{
 		Tag: StmtTagWithExpr{
-			StmtTag: StmtTag{
-				PreWs: preTagWs,
-			},
-			Expr: expr,
+			StmtTag: tag,
+			Expr:    expr,
 		},
 		Body: body,
 	}, nil
 }
 
 func (p *parser) parseIfStmt(preWs string) (Node, error) {
-	ifStmt := IfNode{
-		IfTag: StmtTagWithExpr{
-			StmtTag: StmtTag{
-				PreWs: preWs,
-			},
-		},
-	}
+	var ifStmt IfNode
 
 	p.next() // Consume IF
 	p.consumeWhitespace()
 
-	begTagBody, err := p.parseExpr()
+	begTagBody, err := p.parseTagExpr(token.RSTMT)
 	if err != nil {
 		return nil, err
 	}
 
 	ifStmt.IfTag.Expr = begTagBody
 
-	if p.currentToken.Kind != token.RSTMT {
-		return nil, ExpectedTokensError{
-			Pos:    p.currentToken.Pos,
-			Tokens: []token.Kind{token.RSTMT},
-		}
-	}
-
 	p.next() // Consume RSTMT
 
+	ifStmt.IfTag.StmtTag = p.stmtTag(preWs)
+
 	p.consumeWhitespace()
 	p.consumeLineBreak()
 
@@ -420,25 +633,99 @@ func (p *parser) parseIfStmt(preWs string) (Node, error) {
 	return &ifStmt, nil
 }
 
-func (p *parser) parseGenIfStmt() (Node, error) {
-	genifStmt := GenifNode{
-		StmtTagWithExpr: StmtTagWithExpr{
-			StmtTag: StmtTag{
-				PreWs: "", // TODO: fix
-			},
-		},
-	}
+func (p *parser) parseGenIfStmt(preWs string) (Node, error) {
+	var genifStmt GenifNode
 
 	p.next() // Consume GENIF
 	p.consumeWhitespace()
 
-	body, err := p.parseExpr()
+	body, err := p.parseTagExpr(token.RSTMT)
 	if err != nil {
 		return nil, err
 	}
 
 	genifStmt.Expr = body
 
+	p.next() // Consume RSTMT
+
+	genifStmt.StmtTag = p.stmtTag(preWs)
+
+	p.consumeWhitespace()
+	p.consumeLineBreak()
+
+	return &genifStmt, nil
+}
+
+func (p *parser) parseLetStmt(preWs string) (Node, error) {
+	var letStmt LetNode
+
+	p.next() // Consume LET
+	p.consumeWhitespace()
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	letStmt.Name = name
+
+	if p.currentToken.Kind != token.ASSIGN {
+		return nil, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.ASSIGN},
+		}
+	}
+
+	letStmt.Assign = p.currentToken.Pos
+
+	p.next() // Consume ASSIGN
+	p.consumeWhitespace()
+
+	expr, err := p.parseTagExpr(token.RSTMT)
+	if err != nil {
+		return nil, err
+	}
+
+	letStmt.Expr = expr
+
+	p.next() // Consume RSTMT
+
+	letStmt.StmtTag = p.stmtTag(preWs)
+
+	p.consumeWhitespace()
+	p.consumeLineBreak()
+
+	return &letStmt, nil
+}
+
+func (p *parser) parseExtendStmt(preWs string) (Node, error) {
+	var extendStmt ExtendNode
+
+	p.next() // Consume EXTEND
+	p.consumeWhitespace()
+
+	if p.currentToken.Kind != token.STR {
+		return nil, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.STR},
+		}
+	}
+
+	path, err := p.parseString()
+	if err != nil {
+		return nil, err
+	}
+
+	lit, ok := path.(*StringLit)
+	if !ok {
+		return nil, Error{
+			Pos: path.Pos(),
+			Typ: ErrInterpolatedPath,
+		}
+	}
+
+	extendStmt.Path = *lit
+
 	if p.currentToken.Kind != token.RSTMT {
 		return nil, ExpectedTokensError{
 			Pos:    p.currentToken.Pos,
@@ -448,10 +735,121 @@ func (p *parser) parseGenIfStmt() (Node, error) {
 
 	p.next() // Consume RSTMT
 
+	extendStmt.StmtTag = p.stmtTag(preWs)
+
 	p.consumeWhitespace()
 	p.consumeLineBreak()
 
-	return &genifStmt, nil
+	return &extendStmt, nil
+}
+
+func (p *parser) parseBlockStmt(preWs string) (Node, error) {
+	var blockStmt BlockNode
+
+	p.next() // Consume BLOCK
+	p.consumeWhitespace()
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	blockStmt.BlockTag.Name = name
+
+	if p.currentToken.Kind != token.RSTMT {
+		return nil, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.RSTMT},
+		}
+	}
+
+	p.next() // Consume RSTMT
+
+	blockStmt.BlockTag.StmtTag = p.stmtTag(preWs)
+
+	p.consumeWhitespace()
+	p.consumeLineBreak()
+
+	body, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+
+	blockStmt.Body = body
+
+	preEndTagWs := p.consumeWhitespace()
+
+	if p.currentToken.Kind != token.LSTMT {
+		return nil, Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrEndExpected,
+		}
+	}
+
+	p.next() // Consume LSTMT
+	p.consumeWhitespace()
+
+	if p.currentToken.Kind != token.END {
+		return nil, Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrEndExpected,
+		}
+	}
+
+	if err := p.consumeEndTag(); err != nil {
+		return nil, err
+	}
+
+	blockStmt.EndTag = p.stmtTag(preEndTagWs)
+
+	return &blockStmt, nil
+}
+
+func (p *parser) parseRawStmt(preWs string) (Node, error) {
+	var rawStmt RawNode
+
+	p.next() // Consume RAW
+	p.consumeWhitespace()
+
+	if p.currentToken.Kind != token.RSTMT {
+		return nil, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.RSTMT},
+		}
+	}
+
+	p.next() // Consume RSTMT
+
+	rawStmt.RawTag = p.stmtTag(preWs)
+	rawStmt.ValuePos = p.currentToken.Pos
+
+	// Line breaks are a part of the text, so it is kept as is
+	rawStmt.Val = p.consumeToken(token.RAW_TEXT)
+
+	if p.currentToken.Kind != token.LSTMT {
+		return nil, Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrEndExpected,
+		}
+	}
+
+	p.next() // Consume LSTMT
+	p.consumeWhitespace()
+
+	if p.currentToken.Kind != token.END {
+		return nil, Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrEndExpected,
+		}
+	}
+
+	if err := p.consumeEndTag(); err != nil {
+		return nil, err
+	}
+
+	rawStmt.EndTag = p.stmtTag("")
+
+	return &rawStmt, nil
 }
 
 func (p *parser) parseBody() ([]Node, error) {
@@ -467,9 +865,13 @@ func (p *parser) parseBody() ([]Node, error) {
 			!p.checkNextNTokens(token.DEFAULT) &&
 			!p.checkNextNTokens(token.WS, token.DEFAULT)) {
 		// TODO: refactor
+		start := p.pos
+
 		node, err := p.parseNode()
 		if err != nil {
-			return body, err
+			p.resync(start, err)
+
+			continue
 		}
 
 		if node == nil {
@@ -502,38 +904,27 @@ func (p *parser) parseCases(switchStmt *SwitchNode) error {
 				return err
 			}
 
-			switchStmt.EndTag = StmtTag{PreWs: preTagWs}
+			switchStmt.EndTag = p.stmtTag(preTagWs)
 
 			return nil
 
 		case token.CASE:
-			cc := ClauseWithExpr{
-				Tag: StmtTagWithExpr{
-					StmtTag: StmtTag{
-						PreWs: preTagWs,
-					},
-				},
-			}
+			var cc ClauseWithExpr
 
 			p.next()
 			p.consumeWhitespace()
 
-			cExpr, err := p.parseExpr()
+			cExpr, err := p.parseTagExpr(token.RSTMT)
 			if err != nil {
 				return err
 			}
 
 			cc.Tag.Expr = cExpr
 
-			if p.currentToken.Kind != token.RSTMT {
-				return ExpectedTokensError{
-					Pos:    p.currentToken.Pos,
-					Tokens: []token.Kind{token.RSTMT},
-				}
-			}
-
 			p.next() // Consume RSTMT
 
+			cc.Tag.StmtTag = p.stmtTag(preTagWs)
+
 			p.consumeLineBreak()
 
 			b, err := p.parseBody()
@@ -546,11 +937,7 @@ func (p *parser) parseCases(switchStmt *SwitchNode) error {
 			switchStmt.Cases = append(switchStmt.Cases, cc)
 
 		case token.DEFAULT:
-			d := Clause{
-				Tag: StmtTag{
-					PreWs: preTagWs,
-				},
-			}
+			var d Clause
 
 			p.next()
 			p.consumeWhitespace()
@@ -564,6 +951,8 @@ func (p *parser) parseCases(switchStmt *SwitchNode) error {
 
 			p.next() // Consume RSTMT
 
+			d.Tag = p.stmtTag(preTagWs)
+
 			p.consumeLineBreak()
 
 			b, err := p.parseBody()
@@ -589,7 +978,7 @@ func (p *parser) parseCases(switchStmt *SwitchNode) error {
 				return err
 			}
 
-			switchStmt.EndTag = StmtTag{PreWs: preEndTagWs}
+			switchStmt.EndTag = p.stmtTag(preEndTagWs)
 			switchStmt.DefaultCase = &d
 
 			return nil
@@ -604,39 +993,201 @@ func (p *parser) parseCases(switchStmt *SwitchNode) error {
 }
 
 func (p *parser) parseSwitchStmt(preWs string) (Node, error) {
-	switchStmt := SwitchNode{
-		SwitchTag: StmtTagWithExpr{
-			StmtTag: StmtTag{
-				PreWs: preWs,
-			},
-		},
-	}
+	var switchStmt SwitchNode
 
 	p.next() // Consume SWITCH
 	p.consumeWhitespace()
 
-	begTagBody, err := p.parseExpr()
+	begTagBody, err := p.parseTagExpr(token.RSTMT)
 	if err != nil {
 		return nil, err
 	}
 
 	switchStmt.SwitchTag.Expr = begTagBody
 
-	if p.currentToken.Kind != token.RSTMT {
+	p.next() // Consume RSTMT
+
+	switchStmt.SwitchTag.StmtTag = p.stmtTag(preWs)
+
+	p.consumeWhitespace()
+	p.consumeLineBreak()
+
+	if err := p.parseCases(&switchStmt); err != nil {
+		return nil, err
+	}
+
+	return &switchStmt, nil
+}
+
+func (p *parser) parseIdent() (Ident, error) {
+	if p.currentToken.Kind != token.IDENT {
+		return Ident{}, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.IDENT},
+		}
+	}
+
+	ident := Ident{
+		NamePos: p.currentToken.Pos,
+		Name:    p.currentToken.Val,
+	}
+
+	p.next()
+	p.consumeWhitespace()
+
+	return ident, nil
+}
+
+func (p *parser) parseForElse(forStmt *ForNode) error {
+	preTagWs := p.consumeWhitespace()
+
+	if p.currentToken.Kind != token.LSTMT {
+		return Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrEndExpected,
+		}
+	}
+
+	p.next() // Consume LSTMT
+	p.consumeWhitespace()
+
+	switch p.currentToken.Kind {
+	case token.END:
+		if err := p.consumeEndTag(); err != nil {
+			return err
+		}
+
+		forStmt.EndTag = p.stmtTag(preTagWs)
+
+		return nil
+
+	case token.ELSE:
+		p.next()
+		p.consumeWhitespace()
+
+		if p.currentToken.Kind != token.RSTMT {
+			return ExpectedTokensError{
+				Pos:    p.currentToken.Pos,
+				Tokens: []token.Kind{token.RSTMT},
+			}
+		}
+
+		p.next() // Consume RSTMT
+
+		elseTag := p.stmtTag(preTagWs)
+
+		p.consumeLineBreak()
+
+		elseBody, err := p.parseBody()
+		if err != nil {
+			return err
+		}
+
+		forStmt.Else = Clause{
+			Tag:  elseTag,
+			Body: elseBody,
+		}
+
+		preEndTagWs := p.consumeWhitespace()
+
+		if p.currentToken.Kind != token.LSTMT {
+			return Error{
+				Pos: p.currentToken.Pos,
+				Typ: ErrEndExpected,
+			}
+		}
+
+		p.next() // Consume LSTMT
+		p.consumeWhitespace()
+
+		if p.currentToken.Kind != token.END {
+			return Error{
+				Pos: p.currentToken.Pos,
+				Typ: ErrEndExpected,
+			}
+		}
+
+		if err := p.consumeEndTag(); err != nil {
+			return err
+		}
+
+		forStmt.EndTag = p.stmtTag(preEndTagWs)
+
+		return nil
+
+	default:
+		return Error{
+			Pos: p.currentToken.Pos,
+			Typ: ErrEndExpected,
+		}
+	}
+}
+
+func (p *parser) parseForStmt(preWs string) (Node, error) {
+	var forStmt ForNode
+
+	p.next() // Consume FOR
+	p.consumeWhitespace()
+
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.currentToken.Kind == token.COMMA {
+		p.next() // Consume COMMA
+		p.consumeWhitespace()
+
+		key := ident
+		forStmt.ForTag.Key = &key
+
+		ident, err = p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	forStmt.ForTag.Value = ident
+
+	if p.currentToken.Kind != token.IN {
 		return nil, ExpectedTokensError{
 			Pos:    p.currentToken.Pos,
-			Tokens: []token.Kind{token.RSTMT},
+			Tokens: []token.Kind{token.IN},
 		}
 	}
 
+	forStmt.ForTag.In = Kw{
+		Kind: token.IN,
+		Pos:  p.currentToken.Pos,
+	}
+
+	p.next() // Consume IN
+	p.consumeWhitespace()
+
+	expr, err := p.parseTagExpr(token.RSTMT)
+	if err != nil {
+		return nil, err
+	}
+
+	forStmt.ForTag.Expr = expr
+
 	p.next() // Consume RSTMT
 
+	forStmt.ForTag.StmtTag = p.stmtTag(preWs)
+
 	p.consumeWhitespace()
 	p.consumeLineBreak()
 
-	if err := p.parseCases(&switchStmt); err != nil {
+	body, err := p.parseBody()
+	if err != nil {
 		return nil, err
 	}
 
-	return &switchStmt, nil
+	forStmt.Body = body
+
+	if err := p.parseForElse(&forStmt); err != nil {
+		return nil, err
+	}
+
+	return &forStmt, nil
 }
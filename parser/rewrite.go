@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyFunc is invoked by Rewrite for each element, see Rewrite.
+type ApplyFunc func(*Cursor) bool
+
+// Rewrite traverses an AST recursively, starting with root, and calls pre and post
+// for each element in the same order as Walk. pre is called before the children
+// of the element are traversed, post is called after. Both of them may be nil.
+//
+// If pre returns false, the children of the element and post are skipped.
+// If post returns false, the traversal is stopped.
+//
+// The element may be replaced or deleted using the cursor, children of the
+// replacement are traversed instead of the ones of the original element.
+// The AST is modified in place, Rewrite returns the root, possibly replaced.
+func Rewrite(root Element, pre, post ApplyFunc) (result Element) {
+	parent := &struct{ Root Element }{root}
+
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+
+		result = parent.Root
+	}()
+
+	a := &application{pre: pre, post: post}
+	a.apply(nil, slot{loc: reflect.ValueOf(parent).Elem().Field(0)})
+
+	return parent.Root
+}
+
+// Cursor describes an element encountered during Rewrite.
+type Cursor struct {
+	parent  Element
+	slot    slot
+	deleted bool
+}
+
+// Element returns the current element.
+func (c *Cursor) Element() Element {
+	return c.slot.element()
+}
+
+// Parent returns the parent of the current element, it is nil for the root.
+func (c *Cursor) Parent() Element {
+	return c.parent
+}
+
+// Index returns the index of the current element in the slice containing it,
+// e.g. Body of the IfNode, or -1 if the element is not a part of a slice.
+func (c *Cursor) Index() int {
+	if !c.slot.list.IsValid() {
+		return -1
+	}
+
+	return c.slot.index
+}
+
+// Replace replaces the current element with e. It panics if e can't be stored
+// in the place of the current element, e.g. an Expr in the place of a Node.
+func (c *Cursor) Replace(e Element) {
+	v := reflect.ValueOf(e)
+
+	// Value fields, e.g. Ident of SelectorExpr, are replaced with the value
+	if c.slot.loc.Kind() == reflect.Struct && v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	if !v.IsValid() || !v.Type().AssignableTo(c.slot.loc.Type()) {
+		panic(fmt.Sprintf("parser: %T can't replace %s", e, c.slot.loc.Type()))
+	}
+
+	c.slot.loc.Set(v)
+}
+
+// Delete deletes the current element from the slice containing it.
+// It panics if the element is not a part of a slice.
+func (c *Cursor) Delete() {
+	if !c.slot.list.IsValid() {
+		panic("parser: Delete of an element not contained in a slice")
+	}
+
+	c.deleted = true
+}
+
+var abort = new(int)
+
+type application struct {
+	pre, post ApplyFunc
+}
+
+// apply traverses the element held by s, returns true if it is deleted.
+func (a *application) apply(parent Element, s slot) bool {
+	c := &Cursor{parent: parent, slot: s}
+
+	if (a.pre != nil && !a.pre(c)) || c.deleted {
+		return c.deleted
+	}
+
+	a.applyChildren(c)
+
+	if a.post != nil && !a.post(c) {
+		panic(abort)
+	}
+
+	return c.deleted
+}
+
+func (a *application) applyChildren(c *Cursor) {
+	element := c.Element()
+	apply := func(child slot) bool {
+		return a.apply(element, child)
+	}
+
+	if _, ok := element.(Ast); !ok {
+		eachChild(reflect.ValueOf(element), apply)
+
+		return
+	}
+
+	// Ast is held by value, so its settable copy is traversed and stored back,
+	// even if the traversal is stopped, to keep deletions of the nodes
+	v := reflect.New(reflect.TypeOf(element)).Elem()
+	v.Set(reflect.ValueOf(element))
+
+	defer func() {
+		c.Replace(v.Interface().(Ast)) //nolint: forcetypeassert
+	}()
+
+	eachChild(v, apply)
+}
@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/token"
+	"github.com/flowtemplates/flow-go/value"
+)
+
+// parseString parses the current STR token as a StringLit,
+// or as an InterpolatedLit if it has '${expr}' in it.
+func (p *parser) parseString() (Expr, error) {
+	tok := p.currentToken
+
+	p.next()
+	p.consumeWhitespace()
+
+	raw := tok.Val
+	quote := raw[0]
+	body := raw[1 : len(raw)-1]
+	bodyPos := tok.Pos.Advance(raw[:1])
+
+	if quote == byte(token.BQUOTE) {
+		return &StringLit{
+			ValuePos: tok.Pos,
+			Quote:    quote,
+			Raw:      raw,
+			Value:    value.StringValue(body),
+			ValueEnd: tok.End(),
+		}, nil
+	}
+
+	var (
+		parts []Expr
+		b     strings.Builder
+		// Start of the current text part in the body
+		textStart int
+	)
+
+	addText := func(end int) {
+		if textStart < end {
+			parts = append(parts, &StringLit{
+				ValuePos: bodyPos.Advance(body[:textStart]),
+				Raw:      body[textStart:end],
+				Value:    value.StringValue(b.String()),
+				ValueEnd: bodyPos.Advance(body[:end]),
+			})
+		}
+
+		b.Reset()
+	}
+
+	for i := 0; i < len(body); {
+		switch {
+		case body[i] == '\\':
+			n, err := unescape(&b, body[i:], quote)
+			if err != nil {
+				return nil, Error{
+					Pos: bodyPos.Advance(body[:i]),
+					Typ: ErrInvalidEscape,
+				}
+			}
+
+			i += n
+
+		case quote == byte(token.DQUOTE) && strings.HasPrefix(body[i:], "${"):
+			addText(i)
+
+			n := interpolationLen(body[i+2:])
+			expr, err := parseInterpolation(body[i+2:i+2+n], bodyPos.Advance(body[:i+2]))
+			if err != nil {
+				return nil, err
+			}
+
+			parts = append(parts, expr)
+			i += 2 + n + 1 // Skip '${', the expression and '}'
+			textStart = i
+
+		default:
+			b.WriteByte(body[i])
+			i++
+		}
+	}
+
+	if parts == nil {
+		return &StringLit{
+			ValuePos: tok.Pos,
+			Quote:    quote,
+			Raw:      raw,
+			Value:    value.StringValue(b.String()),
+			ValueEnd: tok.End(),
+		}, nil
+	}
+
+	addText(len(body))
+
+	return &InterpolatedLit{
+		ValuePos: tok.Pos,
+		Raw:      raw,
+		Parts:    parts,
+		ValueEnd: tok.End(),
+	}, nil
+}
+
+// unescape writes the character of the escape sequence at the start of s
+// and returns the length of the sequence. Escapes are the ones of Go,
+// any quote and '$' can be escaped as well.
+func unescape(b *strings.Builder, s string, quote byte) (int, error) {
+	if len(s) < 2 {
+		return 0, strconv.ErrSyntax
+	}
+
+	switch c := s[1]; c {
+	case '\'', '"', '`', '$':
+		b.WriteByte(c)
+
+		return 2, nil
+	}
+
+	r, multibyte, tail, err := strconv.UnquoteChar(s, quote)
+	if err != nil {
+		return 0, err
+	}
+
+	if r < utf8.RuneSelf || !multibyte {
+		b.WriteByte(byte(r))
+	} else {
+		b.WriteRune(r)
+	}
+
+	return len(s) - len(tail), nil
+}
+
+// interpolationLen returns the length of the expression interpolated
+// into a string up to its closing brace, it is scanned as by the lexer.
+func interpolationLen(s string) int {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			i += stringLen(s[i+1:], c)
+
+		case '{':
+			depth++
+
+		case '}':
+			if depth == 0 {
+				return i
+			}
+
+			depth--
+		}
+	}
+
+	return len(s)
+}
+
+// stringLen returns the length of the rest of the string nested in an
+// interpolated expression, including the closing quote.
+func stringLen(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == quote:
+			return i + 1
+
+		case s[i] == '\\' && quote != '`':
+			i++
+
+		case s[i] == '$' && quote == '"' && strings.HasPrefix(s[i:], "${"):
+			i += 2 + interpolationLen(s[i+2:])
+		}
+	}
+
+	return len(s)
+}
+
+// parseInterpolation parses the expression interpolated into a string,
+// which starts at pos.
+func parseInterpolation(src string, pos token.Position) (Expr, error) {
+	tokens := lexer.ExprTokens([]byte(src), pos)
+	tokens = append(tokens, token.Token{Kind: token.EOF, Pos: pos.Advance(src)})
+
+	p := newParser(tokens)
+	p.consumeWhitespace()
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.currentToken.Kind != token.EOF {
+		return nil, ExpectedTokensError{
+			Pos:    p.currentToken.Pos,
+			Tokens: []token.Kind{token.RBRACE},
+		}
+	}
+
+	return expr, nil
+}
@@ -0,0 +1,62 @@
+package parser_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+func TestCustomDelimsPositions(t *testing.T) {
+	src := "<%= a -%> <%# c %>\n<% if b %>x<% end %>"
+
+	ast, err := parser.AstFromBytesWith([]byte(src), lexer.Delims{
+		LExpr: "<%=", RExpr: "%>",
+		LStmt: "<%", RStmt: "%>",
+		LComm: "<%#", RComm: "%>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"<%= a -%>", " ", "<%# c %>", "<% if b %>x<% end %>"}
+	if len(ast) != len(expected) {
+		t.Fatalf("Expected %d nodes, got %d", len(expected), len(ast))
+	}
+
+	for i, node := range ast {
+		if got := src[node.Pos().Offset:node.End().Offset]; got != expected[i] {
+			t.Errorf("Span mismatch.\nExpected: %q\nGot: %q", expected[i], got)
+		}
+	}
+}
+
+func TestInvalidPragma(t *testing.T) {
+	input := "{# flow:delims [[ ]] [% %] #}\n{{ a }}"
+
+	got, err := parser.AstFromBytes([]byte(input))
+
+	var (
+		list      parser.ErrorList
+		delimsErr *lexer.DelimsError
+	)
+	if !errors.As(err, &list) || len(list) != 1 || !errors.As(list[0], &delimsErr) {
+		t.Fatalf("Input: %q\nErrorList with DelimsError expected, got: %v", input, err)
+	}
+
+	// The pragma is parsed as a regular comment with default delimiters
+	expected := parser.Ast{
+		&parser.CommNode{Val: "flow:delims [[ ]] [% %]", PostLB: "\n"},
+		&parser.ExprNode{Body: &parser.Ident{Name: "a"}},
+	}
+
+	a, _ := json.MarshalIndent(expected, "", "  ")
+	b, _ := json.MarshalIndent(got, "", "  ")
+
+	if !slices.Equal(a, b) {
+		t.Errorf("Input: %q\nAST mismatch.\nExpected:\n%s\nGot:\n%s", input, a, b)
+	}
+}
@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/flowtemplates/flow-go/parser"
@@ -322,6 +323,14 @@ func TestExpressionsEdgeCases(t *testing.T) {
 				Typ: parser.ErrExpressionExpected,
 			},
 		},
+		{
+			name:     "Number out of range",
+			input:    "{{ 1" + strings.Repeat("0", 400) + " }}",
+			expected: []parser.Node{},
+			errExpected: parser.Error{
+				Typ: parser.ErrInvalidNumber,
+			},
+		},
 		{
 			name:     "Expression interrupted with EOF",
 			input:    "{{",
@@ -370,16 +379,26 @@ func TestExpressionsEdgeCases(t *testing.T) {
 			name:     "Expression with open statement",
 			input:    "{{ {% }}",
 			expected: []parser.Node{},
-			errExpected: parser.Error{
-				Typ: parser.ErrExpressionExpected,
+			errExpected: parser.ErrorList{
+				parser.Error{
+					Typ: parser.ErrExpressionExpected,
+				},
+				parser.Error{
+					Typ: parser.ErrKeywordExpected,
+				},
 			},
 		},
 		{
 			name:     "Expression with statement",
 			input:    "{{ {%%} }}",
 			expected: []parser.Node{},
-			errExpected: parser.Error{
-				Typ: parser.ErrExpressionExpected,
+			errExpected: parser.ErrorList{
+				parser.Error{
+					Typ: parser.ErrExpressionExpected,
+				},
+				parser.Error{
+					Typ: parser.ErrKeywordExpected,
+				},
 			},
 		},
 		{
@@ -420,6 +439,7 @@ func TestOperators(t *testing.T) {
 					Body: &parser.BinaryExpr{
 						X: &parser.StringLit{
 							Quote: '"',
+							Raw:   `"a"`,
 							Value: value.StringValue("a"),
 						},
 						Op: parser.Kw{
@@ -427,6 +447,7 @@ func TestOperators(t *testing.T) {
 						},
 						Y: &parser.StringLit{
 							Quote: '"',
+							Raw:   `"b"`,
 							Value: value.StringValue("b"),
 						},
 					},
@@ -779,6 +800,250 @@ func TestOperators(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+func TestArithmeticOperators(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Multiplication before addition",
+			input: "{{a+b*c}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.BinaryExpr{
+						X: &parser.Ident{
+							Name: "a",
+						},
+						Op: parser.Kw{
+							Kind: token.ADD,
+						},
+						Y: &parser.BinaryExpr{
+							X: &parser.Ident{
+								Name: "b",
+							},
+							Op: parser.Kw{
+								Kind: token.MUL,
+							},
+							Y: &parser.Ident{
+								Name: "c",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Subtraction is left-associative",
+			input: "{{a - b - 1}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.BinaryExpr{
+						X: &parser.BinaryExpr{
+							X: &parser.Ident{
+								Name: "a",
+							},
+							Op: parser.Kw{
+								Kind: token.MINUS,
+							},
+							Y: &parser.Ident{
+								Name: "b",
+							},
+						},
+						Op: parser.Kw{
+							Kind: token.MINUS,
+						},
+						Y: &parser.NumberLit{
+							Value: value.NumberValue(1),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Arithmetic before comparison",
+			input: "{{a % 2 == 0}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.BinaryExpr{
+						X: &parser.BinaryExpr{
+							X: &parser.Ident{
+								Name: "a",
+							},
+							Op: parser.Kw{
+								Kind: token.MOD,
+							},
+							Y: &parser.NumberLit{
+								Value: value.NumberValue(2),
+							},
+						},
+						Op: parser.Kw{
+							Kind: token.EQL,
+						},
+						Y: &parser.NumberLit{
+							Value: value.NumberValue(0),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Multiplication by negative number",
+			input: "{{a*-2}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.BinaryExpr{
+						X: &parser.Ident{
+							Name: "a",
+						},
+						Op: parser.Kw{
+							Kind: token.MUL,
+						},
+						Y: &parser.NumberLit{
+							Value: value.NumberValue(-2),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Negation of var",
+			input: "{{-a / b}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.BinaryExpr{
+						X: &parser.UnaryExpr{
+							Op: parser.Kw{
+								Kind: token.MINUS,
+							},
+							Expr: &parser.Ident{
+								Name: "a",
+							},
+						},
+						Op: parser.Kw{
+							Kind: token.DIV,
+						},
+						Y: &parser.Ident{
+							Name: "b",
+						},
+					},
+				},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestMemberAccess(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Selector",
+			input: "{{user.name}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.SelectorExpr{
+						X: &parser.Ident{
+							Name: "user",
+						},
+						Sel: parser.Ident{
+							Name: "name",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Selector with keyword",
+			input: "{{ item.default }}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.SelectorExpr{
+						X: &parser.Ident{
+							Name: "item",
+						},
+						Sel: parser.Ident{
+							Name: "default",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Index and selector",
+			input: "{{ items[0].name }}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.SelectorExpr{
+						X: &parser.IndexExpr{
+							X: &parser.Ident{
+								Name: "items",
+							},
+							Index: &parser.NumberLit{
+								Value: value.NumberValue(0),
+							},
+						},
+						Sel: parser.Ident{
+							Name: "name",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Index by string",
+			input: `{{ config["key"] }}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.IndexExpr{
+						X: &parser.Ident{
+							Name: "config",
+						},
+						Index: &parser.StringLit{
+							Quote: '"',
+							Raw:   `"key"`,
+							Value: value.StringValue("key"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Selector binds tighter than operators",
+			input: "{{ !user.active }}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.UnaryExpr{
+						Op: parser.Kw{
+							Kind: token.EXCL,
+						},
+						Expr: &parser.SelectorExpr{
+							X: &parser.Ident{
+								Name: "user",
+							},
+							Sel: parser.Ident{
+								Name: "active",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "Selector without field",
+			input:    "{{ user. }}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.IDENT},
+			},
+		},
+		{
+			name:     "Unclosed index",
+			input:    "{{ items[0 }}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.RBRACK},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func TestTernaries(t *testing.T) {
 	testCases := []testCase{
 		{
@@ -958,6 +1223,147 @@ func TestTernaries(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+func TestStringLiterals(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Escape sequences",
+			input: `{{"a\tb\n\"c\" \u00e9\\"}}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.StringLit{
+						Quote: '"',
+						Raw:   `"a\tb\n\"c\" \u00e9\\"`,
+						Value: value.StringValue("a\tb\n\"c\" \u00e9\\"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Escaped quote in single quotes",
+			input: `{{'it\'s'}}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.StringLit{
+						Quote: '\'',
+						Raw:   `'it\'s'`,
+						Value: value.StringValue("it's"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Raw string",
+			input: "{{`a\\n${b}`}}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.StringLit{
+						Quote: '`',
+						Raw:   "`a\\n${b}`",
+						Value: value.StringValue("a\\n${b}"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Escaped interpolation",
+			input: `{{"\${b}"}}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.StringLit{
+						Quote: '"',
+						Raw:   `"\${b}"`,
+						Value: value.StringValue("${b}"),
+					},
+				},
+			},
+		},
+		{
+			name:  "Interpolation",
+			input: `{{"Hi, ${ name -> upper }!\n"}}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.InterpolatedLit{
+						Raw: `"Hi, ${ name -> upper }!\n"`,
+						Parts: []parser.Expr{
+							&parser.StringLit{
+								Raw:   "Hi, ",
+								Value: value.StringValue("Hi, "),
+							},
+							&parser.FilterExpr{
+								Expr: &parser.Ident{
+									Name: "name",
+								},
+								Filter: parser.Ident{
+									Name: "upper",
+								},
+							},
+							&parser.StringLit{
+								Raw:   `!\n`,
+								Value: value.StringValue("!\n"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Interpolation with nested string",
+			input: `{{"${"a" + b}"}}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.InterpolatedLit{
+						Raw: `"${"a" + b}"`,
+						Parts: []parser.Expr{
+							&parser.BinaryExpr{
+								X: &parser.StringLit{
+									Quote: '"',
+									Raw:   `"a"`,
+									Value: value.StringValue("a"),
+								},
+								Op: parser.Kw{
+									Kind: token.ADD,
+								},
+								Y: &parser.Ident{
+									Name: "b",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Invalid escape sequence",
+			input: `{{"\q"}}`,
+			errExpected: parser.Error{
+				Typ: parser.ErrInvalidEscape,
+			},
+		},
+		{
+			name:  "Empty interpolation",
+			input: `{{"${}"}}`,
+			errExpected: parser.Error{
+				Typ: parser.ErrExpressionExpected,
+			},
+		},
+		{
+			name:  "Unfinished interpolated expression",
+			input: `{{"${a b}"}}`,
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.RBRACE},
+			},
+		},
+		{
+			name:  "Interpolated extend path",
+			input: `{% extend "${name}.flow" %}`,
+			errExpected: parser.Error{
+				Typ: parser.ErrInterpolatedPath,
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func TestFilters(t *testing.T) {
 	testCases := []testCase{
 		{
@@ -1034,6 +1440,79 @@ func TestFilters(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "Filter with arguments",
+			input: `{{ name -> replace("-", _) }}`,
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.FilterExpr{
+						Expr: &parser.Ident{
+							Name: "name",
+						},
+						Filter: parser.Ident{
+							Name: "replace",
+						},
+						Args: []parser.Expr{
+							&parser.StringLit{
+								Quote: '"',
+								Raw:   `"-"`,
+								Value: value.StringValue("-"),
+							},
+							&parser.Ident{
+								Name: "_",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Filter with empty arguments",
+			input: "{{ name -> upper() -> truncate(n + 1) }}",
+			expected: []parser.Node{
+				&parser.ExprNode{
+					Body: &parser.FilterExpr{
+						Expr: &parser.FilterExpr{
+							Expr: &parser.Ident{
+								Name: "name",
+							},
+							Filter: parser.Ident{
+								Name: "upper",
+							},
+							Args: []parser.Expr{},
+						},
+						Filter: parser.Ident{
+							Name: "truncate",
+						},
+						Args: []parser.Expr{
+							&parser.BinaryExpr{
+								X: &parser.Ident{
+									Name: "n",
+								},
+								Op: parser.Kw{
+									Kind: token.ADD,
+								},
+								Y: &parser.NumberLit{
+									Value: value.NumberValue(1),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "Filter arguments without comma",
+			input: "{{ name -> replace(a b) }}",
+			errExpected: parser.ExpectedTokensError{
+				Pos: token.Position{
+					Line:   1,
+					Column: 21,
+					Offset: 20,
+				},
+				Tokens: []token.Kind{token.COMMA, token.RPAREN},
+			},
+		},
 	}
 	runTestCases(t, testCases)
 }
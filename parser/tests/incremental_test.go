@@ -0,0 +1,184 @@
+package parser_test
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+func TestReparse(t *testing.T) {
+	source := "Hello, {{ name }}!\n{% let a = 1 %}\n  {% if a %}\n{{ b }}{% end %}  {{ c -> upper }}\n{# d #}\n{{ e.f }}"
+
+	testCases := []struct {
+		name   string
+		source string
+		old    string
+		new    string
+		// Index of the old node which is expected to be reused after the edit
+		reused int
+	}{
+		{
+			name:   "Expression changed",
+			source: source,
+			old:    "name",
+			new:    "user.name",
+			reused: 3,
+		},
+		{
+			name:   "Lines inserted",
+			source: source,
+			old:    "a = 1",
+			new:    "a =\n\n1",
+			reused: 4,
+		},
+		{
+			name:   "Nodes on the same line are parsed again",
+			source: source,
+			old:    "{{ b }}",
+			new:    "{{ bb }}",
+			reused: 7,
+		},
+		{
+			name:   "Text inserted before indented statement",
+			source: source,
+			old:    "1 %}\n",
+			new:    "1 %}\n}}",
+		},
+		{
+			name:   "Trim marker added",
+			source: source,
+			old:    "!\n{% let",
+			new:    "!\n{%- let",
+		},
+		{
+			name:   "Syntax error",
+			source: source,
+			old:    "e.f",
+			new:    "e.",
+		},
+		{
+			name:   "Statement opened",
+			source: source,
+			old:    "{# d #}",
+			new:    "{% for x in y %}{# d #}",
+		},
+		{
+			name:   "Pragma added",
+			source: source,
+			old:    "Hello",
+			new:    "{# flow:delims [[ ]] [% %] [# #] #}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := lexer.TokensFromBytes([]byte(tc.source))
+
+			ast, err := parser.AstFromBytes([]byte(tc.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			old := append(parser.Ast(nil), ast...)
+
+			edit := lexer.Edit{
+				Offset:  strings.Index(tc.source, tc.old),
+				Deleted: len(tc.old),
+				Text:    tc.new,
+			}
+			source := edit.Apply([]byte(tc.source))
+
+			got, gotTokens, err := parser.Reparse(ast, tokens, source, edit)
+			expected, expectedErr := parser.AstFromBytes(source)
+
+			if !reflect.DeepEqual(err, expectedErr) {
+				t.Errorf("Source: %q\nError mismatch\nWant: %v\nGot: %v", source, expectedErr, err)
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Source: %q\nAST mismatch\nExpected: %#v\nGot: %#v", source, expected, got)
+			}
+
+			if !reflect.DeepEqual(gotTokens, lexer.TokensFromBytes(source)) {
+				t.Errorf("Source: %q\nTokens mismatch", source)
+			}
+
+			if tc.reused != 0 && !containsNode(got, old[tc.reused]) {
+				t.Errorf("Source: %q\nNode %d is not reused", source, tc.reused)
+			}
+		})
+	}
+}
+
+func containsNode(ast parser.Ast, node parser.Node) bool {
+	for _, n := range ast {
+		if n == node {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestReparseRandomEdits(t *testing.T) {
+	sources := []string{
+		"Hello, {{ name }}!\n{% let a = 1 %}\n  {% if a %}\n{{ b }}{% end %}  {{ c -> upper }}\n{# d #}\n{{ e.f }}",
+		"{% for x in xs %}\n  {{- x }}\n{% else %}\n  none\n{% end %}\n{% switch y %}\n{% case 1 %}\none\n{% end %}\n",
+		"a {% if b -%}\n\t{% raw %}{{ c }}{% end %}\n{%- end %} d\n{# e #}  {% let f = 'g' %}\n",
+	}
+
+	fragments := []string{
+		"", " ", "  ", "\t", "\n", "x", "-", "'", "}}", "{{", "{%", "%}", "{#", "#}",
+		"{{ y }}", "{% end %}", "{% if z %}", "{%- let w = 2 -%}", "\n  {% if z %}\n",
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2)) //nolint: gosec
+
+	for _, source := range sources {
+		src := []byte(source)
+		tokens := lexer.TokensFromBytes(src)
+
+		ast, err := parser.AstFromBytes(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for range 1000 {
+			offset := rnd.IntN(len(src) + 1)
+			edit := lexer.Edit{
+				Offset:  offset,
+				Deleted: rnd.IntN(min(len(src)-offset, 4) + 1),
+				Text:    fragments[rnd.IntN(len(fragments))],
+			}
+			newSrc := edit.Apply(src)
+
+			got, gotTokens, err := parser.Reparse(ast, tokens, newSrc, edit)
+			expected, expectedErr := parser.AstFromBytes(newSrc)
+
+			if !reflect.DeepEqual(err, expectedErr) {
+				t.Fatalf("Source: %q\nEdit: %+v\nError mismatch\nWant: %v\nGot: %v", src, edit, expectedErr, err)
+			}
+
+			if !reflect.DeepEqual(gotTokens, lexer.TokensFromBytes(newSrc)) {
+				t.Fatalf("Source: %q\nEdit: %+v\nTokens mismatch", src, edit)
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				t.Fatalf("Source: %q\nEdit: %+v\nAST mismatch\nExpected: %#v\nGot: %#v", src, edit, expected, got)
+			}
+
+			// Edits are applied one after another while the source is valid,
+			// the old AST is changed by Reparse, so it is parsed again otherwise
+			if err == nil {
+				src, tokens, ast = newSrc, gotTokens, got
+			} else {
+				tokens = lexer.TokensFromBytes(src)
+				ast, _ = parser.AstFromBytes(src)
+			}
+		}
+	}
+}
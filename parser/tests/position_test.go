@@ -0,0 +1,80 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+func TestNodePositions(t *testing.T) {
+	src := `Hello {{ user.name -> replace("a", "b") }}!
+{# comment #}
+{% if items[0] > -1 %}
+  {{ (a + b) * c }}
+{% else %}
+  {% let x = y ? 1 : 2 %}
+{% end %}
+{% for i, item in items %}{{ item }}{% end %}
+{{ "é ${ name -> upper }!" }}
+`
+
+	ast, err := parser.AstFromBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := func(s parser.Element) string {
+		return src[s.Pos().Offset:s.End().Offset]
+	}
+
+	exprNode, _ := ast[1].(*parser.ExprNode)
+	filter, _ := exprNode.Body.(*parser.FilterExpr)
+	ifNode, _ := ast[4].(*parser.IfNode)
+	cond, _ := ifNode.IfTag.Expr.(*parser.BinaryExpr)
+	letNode, _ := ifNode.Else.Body[0].(*parser.LetNode)
+	forNode, _ := ast[5].(*parser.ForNode)
+	interpolated, _ := ast[6].(*parser.ExprNode).Body.(*parser.InterpolatedLit)
+
+	testCases := []struct {
+		name     string
+		node     parser.Element
+		expected string
+	}{
+		{"Text", ast[0], "Hello "},
+		{"Expression node", exprNode, `{{ user.name -> replace("a", "b") }}`},
+		{"Filter", filter, `user.name -> replace("a", "b")`},
+		{"Selector", filter.Expr, "user.name"},
+		{"Filter argument", filter.Args[1], `"b"`},
+		{"Text after expression", ast[2], "!\n"},
+		{"Comment", ast[3], "{# comment #}"},
+		{"If", ifNode, src[strings.Index(src, "{% if") : strings.Index(src, "{% for")-1]},
+		{"Condition", cond, "items[0] > -1"},
+		{"Index", cond.X, "items[0]"},
+		{"Negative number", cond.Y, "-1"},
+		{"Binary with parens", ifNode.Main[1].(*parser.ExprNode).Body, "(a + b) * c"},
+		{"Let", letNode, "{% let x = y ? 1 : 2 %}"},
+		{"Ternary", letNode.Expr, "y ? 1 : 2"},
+		{"For", forNode, "{% for i, item in items %}{{ item }}{% end %}"},
+		{"Interpolated string", interpolated, `"é ${ name -> upper }!"`},
+		{"Interpolated text", interpolated.Parts[0], "é "},
+		{"Interpolated expression", interpolated.Parts[1], "name -> upper"},
+		{"Text after interpolation", interpolated.Parts[2], "!"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := text(tc.node); got != tc.expected {
+				t.Errorf("Span mismatch.\nExpected: %q\nGot: %q", tc.expected, got)
+			}
+		})
+	}
+
+	if pos := letNode.Pos(); pos.Line != 6 || pos.Column != 3 {
+		t.Errorf("Unexpected position of let statement: %s", pos)
+	}
+
+	if pos := interpolated.Parts[1].Pos(); pos.Line != 9 || pos.Column != 10 {
+		t.Errorf("Unexpected position of interpolated expression: %s", pos)
+	}
+}
@@ -0,0 +1,128 @@
+package parser_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/token"
+)
+
+func TestErrorRecovery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected parser.Ast
+		errs     []string
+	}{
+		{
+			name:  "Errors in expressions",
+			input: "{{ a b }} and {{ + }} {{ c }}",
+			expected: []parser.Node{
+				&parser.ExprNode{Body: &parser.BadExpr{}},
+				&parser.TextNode{Val: []string{" and "}},
+				&parser.ExprNode{Body: &parser.BadExpr{}},
+				&parser.TextNode{Val: []string{" "}},
+				&parser.ExprNode{Body: &parser.Ident{Name: "c"}},
+			},
+			errs: []string{
+				"1:6: '}}' expected",
+				"1:18: expression expected",
+			},
+		},
+		{
+			name:  "Error in statement body",
+			input: "{% if a %}{{ 1 + }}{% end %}{{ b }}",
+			expected: []parser.Node{
+				&parser.IfNode{
+					IfTag: parser.StmtTagWithExpr{
+						Expr: &parser.Ident{Name: "a"},
+					},
+					Main: []parser.Node{
+						&parser.ExprNode{Body: &parser.BadExpr{}},
+					},
+				},
+				&parser.ExprNode{Body: &parser.Ident{Name: "b"}},
+			},
+			errs: []string{
+				"1:18: expression expected",
+			},
+		},
+		{
+			name:  "Error in opening tag of block statement",
+			input: "{% for item items %}{{ item }}{% end %}{{ b }}",
+			expected: []parser.Node{
+				&parser.BadNode{},
+				&parser.ExprNode{Body: &parser.Ident{Name: "b"}},
+			},
+			errs: []string{
+				"1:13: 'in' expected",
+			},
+		},
+		{
+			name:  "Unknown statement",
+			input: "{% nope %}a{{ b }}",
+			expected: []parser.Node{
+				&parser.TextNode{Val: []string{"a"}},
+				&parser.ExprNode{Body: &parser.Ident{Name: "b"}},
+			},
+			errs: []string{
+				"1:4: " + string(parser.ErrKeywordExpected),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parser.AstFromBytes([]byte(tc.input))
+
+			var list parser.ErrorList
+			if !errors.As(err, &list) {
+				t.Fatalf("Input: %q\nErrorList expected, got: %v", tc.input, err)
+			}
+
+			errs := make([]string, len(list))
+			for i, e := range list {
+				errs[i] = errorPos(e).String() + ": " + e.Error()
+			}
+
+			if !slices.Equal(errs, tc.errs) {
+				t.Errorf("Input: %q\nErrors mismatch.\nExpected:\n%q\nGot:\n%q", tc.input, tc.errs, errs)
+			}
+
+			a, _ := json.MarshalIndent(tc.expected, "", "  ")
+			b, _ := json.MarshalIndent(got, "", "  ")
+
+			if !slices.Equal(a, b) {
+				t.Errorf("Input: %q\nAST mismatch.\nExpected:\n%s\nGot:\n%s", tc.input, a, b)
+			}
+		})
+	}
+}
+
+type linePos token.Position
+
+func (p linePos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+func errorPos(err error) linePos {
+	var (
+		e   parser.Error
+		ete parser.ExpectedTokensError
+	)
+
+	switch {
+	case errors.As(err, &e):
+		return linePos(e.Pos)
+
+	case errors.As(err, &ete):
+		return linePos(ete.Pos)
+
+	default:
+		return linePos{}
+	}
+}
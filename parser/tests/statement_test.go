@@ -325,8 +325,13 @@ Some {{text}}`[1:],
 {%if var%}
 {%`[1:],
 			expected: []parser.Node{},
-			errExpected: parser.Error{
-				Typ: parser.ErrKeywordExpected,
+			errExpected: parser.ErrorList{
+				parser.Error{
+					Typ: parser.ErrKeywordExpected,
+				},
+				parser.Error{
+					Typ: parser.ErrEndExpected,
+				},
 			},
 		},
 		{
@@ -346,8 +351,13 @@ Some {{text}}`[1:],
 {{text}}
 {%`[1:],
 			expected: []parser.Node{},
-			errExpected: parser.Error{
-				Typ: parser.ErrKeywordExpected,
+			errExpected: parser.ErrorList{
+				parser.Error{
+					Typ: parser.ErrKeywordExpected,
+				},
+				parser.Error{
+					Typ: parser.ErrEndExpected,
+				},
 			},
 		},
 		{
@@ -683,3 +693,266 @@ text2
 	}
 	runTestCases(t, testCases)
 }
+
+func TestForStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Simple for statement",
+			input: `
+{%for item in items%}
+{{item}}
+{%end%}`[1:],
+			expected: []parser.Node{
+				&parser.ForNode{
+					ForTag: parser.ForTag{
+						Value: parser.Ident{
+							Name: "item",
+						},
+						In: parser.Kw{
+							Kind: token.IN,
+						},
+						Expr: &parser.Ident{
+							Name: "items",
+						},
+					},
+					Body: []parser.Node{
+						&parser.ExprNode{
+							Body: &parser.Ident{
+								Name: "item",
+							},
+						},
+						&parser.TextNode{
+							Val: []string{"\n"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "For statement with index and else",
+			input: `
+{%for i, item in items%}
+{{i}}
+{%else%}
+empty
+{%end%}`[1:],
+			expected: []parser.Node{
+				&parser.ForNode{
+					ForTag: parser.ForTag{
+						Key: &parser.Ident{
+							Name: "i",
+						},
+						Value: parser.Ident{
+							Name: "item",
+						},
+						In: parser.Kw{
+							Kind: token.IN,
+						},
+						Expr: &parser.Ident{
+							Name: "items",
+						},
+					},
+					Body: []parser.Node{
+						&parser.ExprNode{
+							Body: &parser.Ident{
+								Name: "i",
+							},
+						},
+						&parser.TextNode{
+							Val: []string{"\n"},
+						},
+					},
+					Else: parser.Clause{
+						Body: []parser.Node{
+							&parser.TextNode{
+								Val: []string{"empty", "\n"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestForStatementsEdgeCases(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:     "For statement without end tag",
+			input:    "{%for item in items%}",
+			expected: []parser.Node{},
+			errExpected: parser.Error{
+				Typ: parser.ErrEndExpected,
+			},
+		},
+		{
+			name:     "For statement without in",
+			input:    "{%for item items%}{%end%}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.IN},
+			},
+		},
+		{
+			name:     "For statement without variable",
+			input:    "{%for in items%}{%end%}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.IDENT},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestLetStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Simple let statement",
+			input: `
+{%let a = 1%}
+{{a}}`[1:],
+			expected: []parser.Node{
+				&parser.LetNode{
+					Name: parser.Ident{
+						Name: "a",
+					},
+					Expr: &parser.NumberLit{
+						Value: value.NumberValue(1),
+					},
+				},
+				&parser.ExprNode{
+					Body: &parser.Ident{
+						Name: "a",
+					},
+				},
+			},
+		},
+		{
+			name:  "Let statement with filter",
+			input: "{% let a = name -> upper %}",
+			expected: []parser.Node{
+				&parser.LetNode{
+					Name: parser.Ident{
+						Name: "a",
+					},
+					Expr: &parser.FilterExpr{
+						Expr: &parser.Ident{
+							Name: "name",
+						},
+						Filter: parser.Ident{
+							Name: "upper",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "Let statement without assignment",
+			input:    "{% let a %}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.ASSIGN},
+			},
+		},
+		{
+			name:     "Let statement without name",
+			input:    "{% let = 1 %}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.IDENT},
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestInheritanceStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Extend with block",
+			input: `
+{% extend "base.flow" %}
+{% block body %}
+text
+{% end %}`[1:],
+			expected: []parser.Node{
+				&parser.ExtendNode{
+					Path: parser.StringLit{
+						Quote: '"',
+						Raw:   `"base.flow"`,
+						Value: value.StringValue("base.flow"),
+					},
+				},
+				&parser.BlockNode{
+					BlockTag: parser.BlockTag{
+						Name: parser.Ident{
+							Name: "body",
+						},
+					},
+					Body: []parser.Node{
+						&parser.TextNode{
+							Val: []string{"text", "\n"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "Extend without path",
+			input:    "{% extend base %}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.STR},
+			},
+		},
+		{
+			name:     "Block without name",
+			input:    "{% block %}{% end %}",
+			expected: []parser.Node{},
+			errExpected: parser.ExpectedTokensError{
+				Tokens: []token.Kind{token.IDENT},
+			},
+		},
+		{
+			name:     "Block without end tag",
+			input:    "{% block body %}text",
+			expected: []parser.Node{},
+			errExpected: parser.Error{
+				Typ: parser.ErrEndExpected,
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestRawStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "Raw statement",
+			input: "{% raw %}{{ a }}{% if b %}{% end %}",
+			expected: []parser.Node{
+				&parser.RawNode{
+					Val: "{{ a }}{% if b %}",
+				},
+			},
+		},
+		{
+			name:  "Empty raw statement",
+			input: "{%raw%}{%end%}",
+			expected: []parser.Node{
+				&parser.RawNode{},
+			},
+		},
+		{
+			name:     "Raw statement without end tag",
+			input:    "{% raw %}{{ a }}",
+			expected: []parser.Node{},
+			errExpected: parser.Error{
+				Typ: parser.ErrEndExpected,
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
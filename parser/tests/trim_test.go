@@ -0,0 +1,60 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+func TestTrimMarkers(t *testing.T) {
+	src := "a {{- x -}} b\n{%- if y -%}\nc\n{% end -%}\n"
+
+	ast := mustParse(t, src)
+	if len(ast) != 4 {
+		t.Fatalf("Expected 4 nodes, got %d", len(ast))
+	}
+
+	before, _ := ast[0].(*parser.TextNode)
+	expr, _ := ast[1].(*parser.ExprNode)
+	between, _ := ast[2].(*parser.TextNode)
+	ifNode, _ := ast[3].(*parser.IfNode)
+	body, _ := ifNode.Main[0].(*parser.TextNode)
+
+	testCases := []struct {
+		name     string
+		got      bool
+		expected bool
+	}{
+		{"Text before expression trims right", before.TrimRight, true},
+		{"Text before expression keeps left", before.TrimLeft, false},
+		{"Expression left marker", expr.TrimLeft, true},
+		{"Expression right marker", expr.TrimRight, true},
+		{"Text between tags trims left", between.TrimLeft, true},
+		{"Text between tags trims right", between.TrimRight, true},
+		{"If tag left marker", ifNode.IfTag.TrimLeft, true},
+		{"If tag right marker", ifNode.IfTag.TrimRight, true},
+		{"Body trims left", body.TrimLeft, true},
+		{"Body keeps right", body.TrimRight, false},
+		{"End tag left marker", ifNode.EndTag.TrimLeft, false},
+		{"End tag right marker", ifNode.EndTag.TrimRight, true},
+	}
+
+	for _, tc := range testCases {
+		if tc.got != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.expected, tc.got)
+		}
+	}
+
+	// Whitespace is kept in the text, so the source can be restored
+	if got := src[between.Pos().Offset:between.End().Offset]; got != " b\n" {
+		t.Errorf("Expected text %q, got %q", " b\n", got)
+	}
+
+	if got := src[expr.Pos().Offset:expr.End().Offset]; got != "{{- x -}}" {
+		t.Errorf("Expected expression %q, got %q", "{{- x -}}", got)
+	}
+
+	if got := src[ifNode.Pos().Offset:ifNode.End().Offset]; got != "{%- if y -%}\nc\n{% end -%}" {
+		t.Errorf("Expected if %q, got %q", "{%- if y -%}\nc\n{% end -%}", got)
+	}
+}
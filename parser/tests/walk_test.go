@@ -0,0 +1,257 @@
+package parser_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+func mustParse(t *testing.T, src string) parser.Ast {
+	t.Helper()
+
+	ast, err := parser.AstFromBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ast
+}
+
+func elementTypes(root parser.Element) []string {
+	var res []string
+
+	parser.Inspect(root, func(e parser.Element) bool {
+		if e != nil {
+			res = append(res, strings.TrimLeft(fmt.Sprintf("%T", e), "*parser."))
+		}
+
+		return true
+	})
+
+	return res
+}
+
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Text and expression",
+			input:    "Hello {{ name }}",
+			expected: []string{"Ast", "TextNode", "ExprNode", "Ident"},
+		},
+		{
+			name:  "Unary and filter with arguments",
+			input: `{{ -a -> replace(b, "c") }}`,
+			expected: []string{
+				"Ast", "ExprNode", "FilterExpr", "UnaryExpr", "Ident",
+				"Ident", "Ident", "StringLit",
+			},
+		},
+		{
+			name:  "If with all clauses",
+			input: "{% if a %}1{% else if !b %}2{% else %}{{ c }}{% end %}",
+			expected: []string{
+				"Ast", "IfNode", "Ident", "TextNode", "UnaryExpr", "Ident",
+				"TextNode", "ExprNode", "Ident",
+			},
+		},
+		{
+			name:  "Switch with default case",
+			input: "{% switch x %}{% case 1 %}a{% default %}{{ y.z }}{% end %}",
+			expected: []string{
+				"Ast", "SwitchNode", "Ident", "NumberLit", "TextNode",
+				"ExprNode", "SelectorExpr", "Ident", "Ident",
+			},
+		},
+		{
+			name:  "For with key and else",
+			input: "{% for i, v in list[0] %}{{ v ? i : 0 }}{% else %}{# empty #}{% end %}",
+			expected: []string{
+				"Ast", "ForNode", "Ident", "Ident", "IndexExpr", "Ident", "NumberLit",
+				"ExprNode", "TernaryExpr", "Ident", "Ident", "NumberLit", "CommNode",
+			},
+		},
+		{
+			name:  "Let, block and extend",
+			input: `{% extend "base" %}{% block b %}{% let x = (1 + y) %}{% end %}`,
+			expected: []string{
+				"Ast", "ExtendNode", "StringLit", "BlockNode", "Ident",
+				"LetNode", "Ident", "ParenExpr", "BinaryExpr", "NumberLit", "Ident",
+			},
+		},
+		{
+			name:     "Genif",
+			input:    "{% genif a %}",
+			expected: []string{"Ast", "GenifNode", "Ident"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := elementTypes(mustParse(t, tc.input))
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("Input: %q\nExpected: %v\nGot: %v", tc.input, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestInspectPartialAst(t *testing.T) {
+	ast, _ := parser.AstFromBytes([]byte("{{ a + }}{% if %}{% end %}"))
+
+	expected := []string{"Ast", "ExprNode", "BadExpr", "IfNode", "BadExpr"}
+	if got := elementTypes(ast); !slices.Equal(got, expected) {
+		t.Errorf("Expected: %v\nGot: %v", expected, got)
+	}
+}
+
+type depthVisitor struct {
+	depth *int
+	max   *int
+}
+
+func (v depthVisitor) Visit(e parser.Element) parser.Visitor {
+	if e == nil {
+		*v.depth--
+
+		return nil
+	}
+
+	*v.depth++
+	*v.max = max(*v.max, *v.depth)
+
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	var depth, maxDepth int
+
+	parser.Walk(depthVisitor{&depth, &maxDepth}, mustParse(t, "{% if a %}{{ b.c }}{% end %}"))
+
+	if depth != 0 {
+		t.Errorf("Visit(nil) calls don't match visited elements: depth %d", depth)
+	}
+
+	// Ast, IfNode, ExprNode, SelectorExpr, Ident
+	if maxDepth != 5 {
+		t.Errorf("Expected depth 5, got %d", maxDepth)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	ast := mustParse(t, "{# a #}{{ a }}{% if a %}{# b #}{{ a.a -> upper }}{% end %}")
+
+	result := parser.Rewrite(ast, func(c *parser.Cursor) bool {
+		switch e := c.Element().(type) {
+		case *parser.CommNode:
+			c.Delete()
+
+		case *parser.Ident:
+			if e.Name == "a" {
+				c.Replace(&parser.Ident{NamePos: e.NamePos, Name: "b"})
+			}
+		}
+
+		return true
+	}, nil)
+
+	ast, _ = result.(parser.Ast)
+	if len(ast) != 2 {
+		t.Fatalf("Expected 2 nodes after deletion, got %d", len(ast))
+	}
+
+	ifNode, _ := ast[1].(*parser.IfNode)
+	if len(ifNode.Main) != 1 {
+		t.Errorf("Expected comment to be deleted from the if body, got %d nodes", len(ifNode.Main))
+	}
+
+	var names []string
+
+	parser.Inspect(ast, func(e parser.Element) bool {
+		if ident, ok := e.(*parser.Ident); ok {
+			names = append(names, ident.Name)
+		}
+
+		return true
+	})
+
+	// The selected field is an identifier as well
+	expected := []string{"b", "b", "b", "b", "upper"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected: %v\nGot: %v", expected, names)
+	}
+}
+
+func TestRewriteParentAndIndex(t *testing.T) {
+	ast := mustParse(t, "{% if a %}x{{ b }}{% end %}")
+
+	var got []string
+
+	parser.Rewrite(ast, func(c *parser.Cursor) bool {
+		if _, ok := c.Element().(*parser.ExprNode); ok {
+			got = append(got, fmt.Sprintf("%T %d", c.Parent(), c.Index()))
+		}
+
+		if _, ok := c.Element().(*parser.Ident); ok {
+			got = append(got, fmt.Sprintf("%T %d", c.Parent(), c.Index()))
+		}
+
+		return true
+	}, nil)
+
+	expected := []string{"*parser.IfNode -1", "*parser.IfNode 1", "*parser.ExprNode -1"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected: %v\nGot: %v", expected, got)
+	}
+}
+
+func TestRewriteStop(t *testing.T) {
+	ast := mustParse(t, "{# a #}{{ a }}{# b #}")
+
+	var visited int
+
+	result := parser.Rewrite(ast, func(c *parser.Cursor) bool {
+		if _, ok := c.Element().(*parser.CommNode); ok {
+			c.Delete()
+		}
+
+		return true
+	}, func(c *parser.Cursor) bool {
+		visited++
+
+		_, ok := c.Element().(*parser.Ident)
+
+		return !ok
+	})
+
+	ast, _ = result.(parser.Ast)
+	if len(ast) != 2 {
+		t.Errorf("Expected deletion before the stop to be kept, got %d nodes", len(ast))
+	}
+
+	if visited != 1 {
+		t.Errorf("Expected post to stop at the first identifier, called %d times", visited)
+	}
+}
+
+func TestRewriteInvalidReplace(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on replacing an expression with a node")
+		}
+	}()
+
+	parser.Rewrite(mustParse(t, "{{ a }}"), func(c *parser.Cursor) bool {
+		if _, ok := c.Element().(*parser.Ident); ok {
+			c.Replace(&parser.TextNode{})
+		}
+
+		return true
+	}, nil)
+}
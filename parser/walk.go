@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"reflect"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Element is a part of the template traversed by Walk: an Ast, a Node or an Expr.
+type Element interface {
+	Pos() token.Position
+	End() token.Position
+}
+
+func (a Ast) Pos() token.Position {
+	if len(a) == 0 {
+		return token.Position{}
+	}
+
+	return a[0].Pos()
+}
+
+func (a Ast) End() token.Position {
+	if len(a) == 0 {
+		return token.Position{}
+	}
+
+	return a[len(a)-1].End()
+}
+
+// A Visitor's Visit method is invoked for each element encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of element with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(element Element) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling v.Visit(element);
+// element must not be nil. If the visitor w returned by v.Visit(element) is not nil,
+// Walk is invoked recursively with visitor w for each of the non-nil children
+// of element in the order they appear in the source, followed by a call of w.Visit(nil).
+//
+// Children are found by the fields of the nodes, including ones of tags and clauses,
+// so new node and expression types are traversed without changes to Walk.
+func Walk(v Visitor, element Element) {
+	if v = v.Visit(element); v == nil {
+		return
+	}
+
+	eachChild(reflect.ValueOf(element), func(s slot) bool {
+		Walk(v, s.element())
+
+		return false
+	})
+
+	v.Visit(nil)
+}
+
+type inspector func(Element) bool
+
+func (f inspector) Visit(element Element) Visitor {
+	if f(element) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling f(element);
+// element must not be nil. If f returns true, Inspect invokes f recursively for each
+// of the non-nil children of element, followed by a call of f(nil).
+func Inspect(element Element, f func(Element) bool) {
+	Walk(inspector(f), element)
+}
+
+var (
+	nodeType = reflect.TypeFor[Node]()
+	exprType = reflect.TypeFor[Expr]()
+)
+
+// slot is a location in the AST holding an element.
+type slot struct {
+	// loc is a settable field or slice element
+	loc reflect.Value
+	// list is a slice containing loc, it is invalid for fields
+	list  reflect.Value
+	index int
+}
+
+func (s slot) element() Element {
+	// Value fields, e.g. Ident of SelectorExpr, implement Element by pointer
+	if s.loc.Kind() == reflect.Struct {
+		return s.loc.Addr().Interface().(Element) //nolint: forcetypeassert
+	}
+
+	return s.loc.Interface().(Element) //nolint: forcetypeassert
+}
+
+// eachChild calls f for each non-nil child of the element held by v,
+// looking through tags, clauses and other parts that are not elements themselves.
+// If f returns true, the child is removed from the slice containing it.
+func eachChild(v reflect.Value, f func(slot) bool) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			eachChild(v.Elem(), f)
+		}
+
+	case reflect.Struct:
+		for i := range v.NumField() {
+			field := v.Field(i)
+
+			switch {
+			case isElement(field.Type()):
+				if !isNil(field) {
+					f(slot{loc: field})
+				}
+
+			case mayContainElements(field.Type()):
+				eachChild(field, f)
+			}
+		}
+
+	case reflect.Slice:
+		if !isElement(v.Type().Elem()) {
+			for i := range v.Len() {
+				eachChild(v.Index(i), f)
+			}
+
+			return
+		}
+
+		for i := 0; i < v.Len(); {
+			item := v.Index(i)
+			if !isNil(item) && f(slot{loc: item, list: v, index: i}) {
+				v.Set(reflect.AppendSlice(v.Slice(0, i), v.Slice(i+1, v.Len())))
+
+				continue
+			}
+
+			i++
+		}
+	}
+}
+
+// isElement reports whether t holds a node or an expression.
+// Tags and clauses are not elements, their fields are traversed instead.
+func isElement(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return t.Implements(nodeType) || t.Implements(exprType)
+
+	case reflect.Struct:
+		return isElement(reflect.PointerTo(t))
+
+	default:
+		return false
+	}
+}
+
+func mayContainElements(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return isElement(t.Elem()) || mayContainElements(t.Elem())
+
+	case reflect.Struct:
+		return t.PkgPath() == nodeType.PkgPath()
+
+	default:
+		return false
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+
+	default:
+		return false
+	}
+}
@@ -0,0 +1,74 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flowtemplates/flow-go/token"
+)
+
+// Error is an error of rendering positioned at the expression it occurred in,
+// so it can be reported with diagnostic.FromError.
+type Error struct {
+	Pos token.Position
+	Err error
+}
+
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e Error) Position() token.Position {
+	return e.Pos
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// TemplateError is an error in the template loaded by name, e.g. the parent
+// of {% extend %}, rather than in the rendered one. Its positions point
+// to Source, so diagnostic.FromError reports it against that template.
+type TemplateError struct {
+	Name   string
+	Source []byte
+	Err    error
+}
+
+func (e TemplateError) Error() string {
+	return fmt.Sprintf("template %s: %v", e.Name, e.Err)
+}
+
+func (e TemplateError) Template() (string, []byte) {
+	return e.Name, e.Source
+}
+
+func (e TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt positions err at pos, unless it is positioned already by a nested
+// expression. Exceeded limits and canceled renderings are not positioned,
+// as they are not caused by the expression. Errors in loaded templates
+// are wrapped in TemplateError.
+func (s *state) errorAt(pos token.Position, err error) error {
+	var (
+		posErr   Error
+		limitErr *LimitError
+	)
+
+	switch {
+	case errors.As(err, &posErr), errors.As(err, &limitErr),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	}
+
+	err = Error{Pos: pos, Err: err}
+
+	if t := s.template; t != nil {
+		return TemplateError{Name: t.name, Source: t.src, Err: err}
+	}
+
+	return err
+}
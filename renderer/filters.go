@@ -1,44 +1,69 @@
 package renderer
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/flowtemplates/flow-go/types"
 	"github.com/flowtemplates/flow-go/value"
 	"github.com/iancoleman/strcase"
 )
 
-type filter func(value.Valuable) value.Valuable
+// Filter is a function applied to the value piped into it,
+// e.g. {{ name -> replace("-", "_") }}.
+type Filter struct {
+	// Type of the value filter is applied to, nil means any
+	Input types.Type
+	// Type of the result, nil means any
+	Output types.Type
+	// Types of the arguments passed in parentheses after the filter name
+	Params []types.Type
+	Func   func(v value.Valuable, args []value.Valuable) (value.Valuable, error)
+}
 
-var filtersMap = map[string]filter{
-	"upper": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strings.ToUpper(v.AsString()))
-	},
-	"lower": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strings.ToLower(v.AsString()))
-	},
-	"pascal": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strcase.ToCamel(v.AsString()))
-	},
-	"camel": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strcase.ToLowerCamel(v.AsString()))
-	},
-	"kebab": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strcase.ToKebab(v.AsString()))
-	},
-	"snake": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strcase.ToSnake(v.AsString()))
-	},
-	"capitalize": func(v value.Valuable) value.Valuable {
-		s := v.AsString()
+// Filters is a registry of filters available in templates by their names.
+type Filters map[string]Filter
 
-		return value.StringValue(string(unicode.ToUpper(rune(s[0]))) + s[1:])
-	},
-	"title": func(v value.Valuable) value.Valuable {
+// stringFilter makes a filter without arguments from string transformation.
+func stringFilter(f func(string) string) Filter {
+	return Filter{
+		Input:  types.String,
+		Output: types.String,
+		Func: func(v value.Valuable, _ []value.Valuable) (value.Valuable, error) {
+			return value.StringValue(f(v.AsString())), nil
+		},
+	}
+}
+
+// DefaultFilters returns a new registry with built-in filters,
+// it can be extended with custom ones and set to [Environment].
+func DefaultFilters() Filters {
+	return maps.Clone(builtinFilters)
+}
+
+var builtinFilters = Filters{
+	"upper":  stringFilter(strings.ToUpper),
+	"lower":  stringFilter(strings.ToLower),
+	"pascal": stringFilter(strcase.ToCamel),
+	"camel":  stringFilter(strcase.ToLowerCamel),
+	"kebab":  stringFilter(strcase.ToKebab),
+	"snake":  stringFilter(strcase.ToSnake),
+	"capitalize": stringFilter(func(s string) string {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 {
+			return s
+		}
+
+		return string(unicode.ToUpper(r)) + s[size:]
+	}),
+	"title": stringFilter(func(s string) string {
 		var sb strings.Builder
 		prevSpace := true
-		for _, c := range v.AsString() {
+		for _, c := range s {
 			if unicode.IsSpace(c) {
 				prevSpace = true
 			} else if prevSpace {
@@ -49,21 +74,84 @@ var filtersMap = map[string]filter{
 			sb.WriteRune(c)
 		}
 
-		return value.StringValue(sb.String())
+		return sb.String()
+	}),
+	"length": {
+		Output: types.Number,
+		Func: func(v value.Valuable, _ []value.Valuable) (value.Valuable, error) {
+			switch v := v.(type) {
+			case value.ListValue:
+				return value.NumberValue(len(v)), nil
+
+			case value.MapValue:
+				return value.NumberValue(len(v)), nil
+
+			default:
+				return value.NumberValue(utf8.RuneCountInString(v.AsString())), nil
+			}
+		},
 	},
-	"length": func(v value.Valuable) value.Valuable {
-		return value.NumberValue(len(v.AsString()))
+	"trim": stringFilter(strings.TrimSpace),
+	"replace": {
+		Input:  types.String,
+		Output: types.String,
+		Params: []types.Type{types.String, types.String},
+		Func: func(v value.Valuable, args []value.Valuable) (value.Valuable, error) {
+			return value.StringValue(strings.ReplaceAll(v.AsString(), args[0].AsString(), args[1].AsString())), nil
+		},
 	},
-	"trim": func(v value.Valuable) value.Valuable {
-		return value.StringValue(strings.TrimSpace(v.AsString()))
+	"truncate": {
+		Input:  types.String,
+		Output: types.String,
+		Params: []types.Type{types.Number},
+		Func: func(v value.Valuable, args []value.Valuable) (value.Valuable, error) {
+			n := int(args[0].AsNumber())
+			if n < 0 {
+				return nil, errors.New("negative length")
+			}
+
+			s := []rune(v.AsString())
+			if len(s) > n {
+				s = s[:n]
+			}
+
+			return value.StringValue(s), nil
+		},
+	},
+	"join": {
+		Input:  types.ListType{Elem: types.Any},
+		Output: types.String,
+		Params: []types.Type{types.String},
+		Func: func(v value.Valuable, args []value.Valuable) (value.Valuable, error) {
+			list, ok := v.(value.ListValue)
+			if !ok {
+				return nil, errors.New("list expected")
+			}
+
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = item.AsString()
+			}
+
+			return value.StringValue(strings.Join(items, args[0].AsString())), nil
+		},
 	},
 }
 
-func callFilter(name string, v value.Valuable) (value.Valuable, error) {
-	f, ok := filtersMap[name]
+func (s *state) callFilter(name string, v value.Valuable, args []value.Valuable) (value.Valuable, error) {
+	f, ok := s.env.filters()[name]
 	if !ok {
 		return nil, fmt.Errorf("filter %s is not declared", name)
 	}
 
-	return f(v), nil
+	if len(args) != len(f.Params) {
+		return nil, fmt.Errorf("filter %s expects %d arguments, got %d", name, len(f.Params), len(args))
+	}
+
+	res, err := f.Func(v, args)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %w", name, err)
+	}
+
+	return res, nil
 }
@@ -0,0 +1,118 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+var (
+	ErrNoLoader          = errors.New("template loader is not set")
+	ErrExtendNotTopLevel = errors.New("extend is allowed only at the top level of template")
+	ErrExtendCycle       = errors.New("cyclic extend")
+)
+
+// resolveExtends returns the root template of the inheritance chain of ast.
+// Blocks of child templates are collected to override the ones of their parents,
+// their genif conditions are checked as they are not rendered.
+func (s *state) resolveExtends(ast []parser.Node, context *Context) ([]parser.Node, error) {
+	visited := make(map[string]bool)
+
+	for {
+		extend := findExtend(ast)
+		if extend == nil {
+			return ast, nil
+		}
+
+		if err := s.checkGenifs(ast, context); err != nil {
+			return nil, err
+		}
+
+		collectBlocks(ast, s.template, s.blocks)
+
+		name := extend.Path.Value.AsString()
+		if visited[name] {
+			return nil, fmt.Errorf("%w: %s", ErrExtendCycle, name)
+		}
+
+		visited[name] = true
+
+		if limit := s.env.Limits.MaxIncludeDepth; limit > 0 && len(visited) > limit {
+			return nil, &LimitError{Err: ErrDepthLimit, Limit: int64(limit)}
+		}
+
+		if err := s.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if s.env.Loader == nil {
+			return nil, ErrNoLoader
+		}
+
+		t, err := s.env.parseTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+
+		ast, s.template = t.ast, t
+	}
+}
+
+func findExtend(ast []parser.Node) *parser.ExtendNode {
+	for _, node := range ast {
+		if extend, ok := node.(*parser.ExtendNode); ok {
+			return extend
+		}
+	}
+
+	return nil
+}
+
+// block is the body of the block with the template it is defined in,
+// nil for the rendered one.
+type block struct {
+	body     []parser.Node
+	template *namedTemplate
+}
+
+// collectBlocks adds bodies of the blocks of the template, including nested
+// ones, to blocks unless they are already overridden.
+func collectBlocks(ast []parser.Node, t *namedTemplate, blocks map[string]block) {
+	for _, node := range ast {
+		n, ok := node.(*parser.BlockNode)
+		if !ok {
+			continue
+		}
+
+		if _, exists := blocks[n.BlockTag.Name.Name]; !exists {
+			blocks[n.BlockTag.Name.Name] = block{body: n.Body, template: t}
+		}
+
+		collectBlocks(n.Body, t, blocks)
+	}
+}
+
+// renderBlock renders the body of the block, errors in it are reported
+// against the template it is defined in.
+func (s *state) renderBlock(w io.Writer, b block, context *Context) error {
+	outer := s.template
+	s.template = b.template
+
+	defer func() { s.template = outer }()
+
+	return s.render(w, b.body, context)
+}
+
+func (s *state) checkGenifs(ast []parser.Node, context *Context) error {
+	for _, node := range ast {
+		if genif, ok := node.(*parser.GenifNode); ok {
+			if err := s.render(io.Discard, []parser.Node{genif}, context); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,12 +1,57 @@
 package renderer
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 
+	"github.com/flowtemplates/flow-go/lexer"
 	"github.com/flowtemplates/flow-go/parser"
 )
 
-func RenderAst(ast []parser.Node, scope Input) ([]byte, error) {
+// ErrSkipFile is returned instead of the output when condition
+// of {% genif %} is false, so the file must not be generated.
+var ErrSkipFile = errors.New("file generation skipped by genif")
+
+// Environment holds configuration shared by renderings of templates.
+type Environment struct {
+	// Loader resolves templates referenced by other templates,
+	// rendering of templates with {% extend %} fails if it is nil.
+	Loader Loader
+	// Filters available in templates, built-in ones are used if it is nil
+	Filters Filters
+	// Limits of resources used by a rendering
+	Limits Limits
+	// Delims of templates parsed by the environment, including loaded ones,
+	// default delimiters are used for empty fields
+	Delims lexer.Delims
+	// Cache keeps loaded templates parsed between renderings if it is set
+	Cache *ParseCache
+}
+
+func (e *Environment) filters() Filters {
+	if e.Filters == nil {
+		return builtinFilters
+	}
+
+	return e.Filters
+}
+
+var defaultEnvironment = &Environment{}
+
+// Render writes output of the template to w as it is rendered, without buffering it.
+// If the rendering fails, e.g. with ErrSkipFile, the output written before
+// the failure is not reverted.
+func (e *Environment) Render(w io.Writer, ast []parser.Node, scope Input) error {
+	return e.RenderContext(context.Background(), w, ast, scope)
+}
+
+// RenderContext is like Render, but stops when ctx is done, returning its error.
+// The rendering is also stopped with *LimitError when it exceeds Limits
+// of the environment.
+func (e *Environment) RenderContext(ctx context.Context, w io.Writer, ast []parser.Node, scope Input) error {
 	// tm := make(analyzer.TypeMap)
 	// if errs := analyzer.GetTypeMapFromAst(ast, tm); len(errs) != 0 {
 	// 	return "", errs[0] // TODO: error handling
@@ -14,21 +59,56 @@ func RenderAst(ast []parser.Node, scope Input) ([]byte, error) {
 	// if errs := analyzer.Typecheck(scope, tm); len(errs) != 0 {
 	// 	return "", errs[0] // TODO: error handling
 	// }
-	context := InputToContext(scope)
+	s := state{
+		env:    e,
+		ctx:    ctx,
+		blocks: make(map[string]block),
+	}
 
-	return render(ast, context)
-}
+	if limit := e.Limits.MaxOutputBytes; limit > 0 {
+		w = &limitedWriter{w: w, limit: limit, remaining: limit}
+	}
 
-func RenderBytes(input []byte, scope Input) ([]byte, error) {
-	ast, err := parser.AstFromBytes(input)
+	vars := InputToContext(scope)
+
+	ast, err := s.resolveExtends(ast, vars)
 	if err != nil {
-		return nil, fmt.Errorf("ast from bytes: %w", err)
+		return err
 	}
 
-	res, err := RenderAst(ast, scope)
-	if err != nil {
+	return s.render(w, ast, vars)
+}
+
+func (e *Environment) RenderAst(ast []parser.Node, scope Input) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.Render(&buf, ast, scope); err != nil {
 		return nil, err
 	}
 
-	return res, nil
+	return buf.Bytes(), nil
+}
+
+func (e *Environment) RenderBytes(input []byte, scope Input) ([]byte, error) {
+	ast, err := parser.AstFromBytesWith(input, e.Delims)
+	if err != nil {
+		return nil, fmt.Errorf("ast from bytes: %w", err)
+	}
+
+	return e.RenderAst(ast, scope)
+}
+
+func Render(w io.Writer, ast []parser.Node, scope Input) error {
+	return defaultEnvironment.Render(w, ast, scope)
+}
+
+func RenderContext(ctx context.Context, w io.Writer, ast []parser.Node, scope Input) error {
+	return defaultEnvironment.RenderContext(ctx, w, ast, scope)
+}
+
+func RenderAst(ast []parser.Node, scope Input) ([]byte, error) {
+	return defaultEnvironment.RenderAst(ast, scope)
+}
+
+func RenderBytes(input []byte, scope Input) ([]byte, error) {
+	return defaultEnvironment.RenderBytes(input, scope)
 }
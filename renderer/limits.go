@@ -0,0 +1,88 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrOutputLimit = errors.New("output size limit exceeded")
+	ErrStepLimit   = errors.New("evaluation step limit exceeded")
+	ErrDepthLimit  = errors.New("include depth limit exceeded")
+)
+
+// Limits bound resources used by a single rendering, so templates from
+// untrusted sources can be rendered safely. Zero values mean no limit.
+type Limits struct {
+	// MaxOutputBytes is the maximum size of the output
+	MaxOutputBytes int64
+	// MaxSteps is the maximum number of rendered nodes, loop iterations
+	// and evaluated expressions
+	MaxSteps int64
+	// MaxIncludeDepth is the maximum number of templates loaded
+	// by {% extend %} during a rendering
+	MaxIncludeDepth int
+}
+
+// LimitError is returned when the rendering exceeds one of the Limits.
+// Err is ErrOutputLimit, ErrStepLimit or ErrDepthLimit.
+type LimitError struct {
+	Err   error
+	Limit int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: limit is %d", e.Err, e.Limit)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// How often the context is checked for cancellation, in steps
+const ctxCheckInterval = 256
+
+// step accounts for a unit of rendering work, it fails if the step limit
+// is exceeded or the context of the rendering is done.
+func (s *state) step() error {
+	if s.steps%ctxCheckInterval == 0 {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	s.steps++
+
+	if limit := s.env.Limits.MaxSteps; limit > 0 && s.steps > limit {
+		return &LimitError{Err: ErrStepLimit, Limit: limit}
+	}
+
+	return nil
+}
+
+// limitedWriter fails writes after limit bytes are written to w.
+type limitedWriter struct {
+	w         io.Writer
+	limit     int64
+	remaining int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= l.remaining {
+		n, err := l.w.Write(p)
+		l.remaining -= int64(n)
+
+		return n, err
+	}
+
+	// Output is written up to the limit
+	n, err := l.w.Write(p[:l.remaining])
+	l.remaining -= int64(n)
+
+	if err != nil {
+		return n, err
+	}
+
+	return n, &LimitError{Err: ErrOutputLimit, Limit: l.limit}
+}
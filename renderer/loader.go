@@ -0,0 +1,106 @@
+package renderer
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/flowtemplates/flow-go/parser"
+)
+
+// Loader provides sources of templates referenced by name,
+// e.g. in {% extend "base.flow" %}.
+type Loader interface {
+	Load(name string) ([]byte, error)
+}
+
+// MapLoader loads templates from memory by their names.
+type MapLoader map[string]string
+
+func (l MapLoader) Load(name string) ([]byte, error) {
+	src, ok := l[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	}
+
+	return []byte(src), nil
+}
+
+// FSLoader loads templates from files, names are paths inside of FS.
+type FSLoader struct {
+	FS fs.FS
+}
+
+func (l FSLoader) Load(name string) ([]byte, error) {
+	src, err := fs.ReadFile(l.FS, name)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	return src, nil
+}
+
+// ParseCache keeps templates loaded by renderings of an environment parsed,
+// so each of them is loaded and parsed once. Sources of the loader must not
+// change while the cache is used. It is safe for concurrent use, the zero
+// value is an empty cache.
+type ParseCache struct {
+	mu        sync.Mutex
+	templates map[string]*namedTemplate
+}
+
+func (c *ParseCache) get(name string) (*namedTemplate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.templates[name]
+
+	return t, ok
+}
+
+func (c *ParseCache) put(t *namedTemplate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.templates == nil {
+		c.templates = make(map[string]*namedTemplate)
+	}
+
+	c.templates[t.name] = t
+}
+
+// namedTemplate is a template loaded by name, its source is kept
+// to report errors in it.
+type namedTemplate struct {
+	name string
+	src  []byte
+	ast  parser.Ast
+}
+
+// parseTemplate loads and parses the template, or returns the cached one.
+// Renderings only read ASTs, so they may share them.
+func (e *Environment) parseTemplate(name string) (*namedTemplate, error) {
+	if e.Cache != nil {
+		if t, ok := e.Cache.get(name); ok {
+			return t, nil
+		}
+	}
+
+	src, err := e.Loader.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	ast, err := parser.AstFromBytesWith(src, e.Delims)
+	if err != nil {
+		return nil, TemplateError{Name: name, Source: src, Err: err}
+	}
+
+	t := &namedTemplate{name: name, src: src, ast: ast}
+
+	if e.Cache != nil {
+		e.Cache.put(t)
+	}
+
+	return t, nil
+}
@@ -1,9 +1,13 @@
 package renderer
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
 
 	"github.com/flowtemplates/flow-go/parser"
 	"github.com/flowtemplates/flow-go/token"
@@ -12,135 +16,358 @@ import (
 
 type Input map[string]any
 
-// Map with variables and their values
-type Context map[string]value.Valuable
+var ErrDivisionByZero = errors.New("division by zero")
+
+// state holds data shared by all nodes during a single rendering.
+type state struct {
+	env *Environment
+	ctx context.Context
+	// Blocks overridden by child templates
+	blocks map[string]block
+	// Loaded template being rendered, nil for the rendered one
+	template *namedTemplate
+	// Number of steps done, see Limits.MaxSteps
+	steps int64
+}
+
+// Context is a chain of scopes with variables and their values.
+// Variables declared in a scope are visible in its child scopes,
+// but not in the parent one.
+type Context struct {
+	vars map[string]value.Valuable
+	// Input of the template, its values are converted when they are used
+	// for the first time, so unused parts of large inputs are never converted.
+	input  Input
+	parent *Context
+}
 
-func InputToContext(scope Input) Context {
-	context := make(Context)
-	for name, val := range scope {
-		context[name] = value.FromAny(val)
+func NewContext() *Context {
+	return &Context{
+		vars: make(map[string]value.Valuable),
 	}
+}
+
+func InputToContext(scope Input) *Context {
+	context := NewContext()
+	context.input = scope
 
 	// TODO: check overwrite
-	context["true"] = value.BooleanValue(true)
-	context["false"] = value.BooleanValue(false)
+	context.Set("true", value.BooleanValue(true))
+	context.Set("false", value.BooleanValue(false))
 
 	return context
 }
 
-func render(ast []parser.Node, context Context) ([]byte, error) {
-	var buf bytes.Buffer
+// Child returns new scope nested in the context.
+func (c *Context) Child() *Context {
+	return &Context{
+		vars:   make(map[string]value.Valuable),
+		parent: c,
+	}
+}
+
+// Get looks up variable in the context and all of its parents.
+// It fails if the input value of the variable can't be converted.
+func (c *Context) Get(name string) (value.Valuable, bool, error) {
+	for scope := c; scope != nil; scope = scope.parent {
+		if v, ok := scope.vars[name]; ok {
+			return v, true, nil
+		}
+
+		if val, ok := scope.input[name]; ok {
+			v, err := value.FromAny(val)
+			if err != nil {
+				return nil, true, fmt.Errorf("variable %s: %w", name, err)
+			}
+
+			scope.vars[name] = v
+
+			return v, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// Set declares variable in the current scope, shadowing variables of the parents.
+func (c *Context) Set(name string, v value.Valuable) {
+	c.vars[name] = v
+}
 
+// render writes output of the nodes to w as soon as it is produced.
+func (s *state) render(w io.Writer, ast []parser.Node, context *Context) error {
 	for _, node := range ast {
+		if err := s.step(); err != nil {
+			return err
+		}
+
 		switch n := node.(type) {
 		case *parser.TextNode:
-			for _, s := range n.Val {
-				buf.WriteString(s)
+			if _, err := io.WriteString(w, text(n)); err != nil {
+				return err
 			}
 
+		case *parser.RawNode:
+			if _, err := io.WriteString(w, rawText(n)); err != nil {
+				return err
+			}
+
+		case *parser.CommNode:
+			// Comments are not rendered
+
 		case *parser.ExprNode:
-			s, err := exprToValue(n.Body, context)
+			v, err := s.exprToValue(n.Body, context)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			buf.WriteString(s.AsString())
+			if _, err := io.WriteString(w, v.AsString()); err != nil {
+				return err
+			}
 
 		case *parser.IfNode:
-			conditionValue, err := exprToValue(n.IfTag.Expr, context)
-			if err != nil {
-				return nil, err
+			if err := s.renderIf(w, n, context); err != nil {
+				return err
 			}
 
-			if conditionValue.AsBoolean() {
-				bodyContent, err := render(n.Main, context)
-				if err != nil {
-					return nil, err
-				}
+		case *parser.SwitchNode:
+			if err := s.renderSwitch(w, n, context); err != nil {
+				return err
+			}
 
-				buf.Write(bodyContent)
+		case *parser.LetNode:
+			v, err := s.exprToValue(n.Expr, context)
+			if err != nil {
+				return err
+			}
 
-				return buf.Bytes(), nil
+			context.Set(n.Name.Name, v)
+
+		case *parser.GenifNode:
+			condition, err := s.exprToValue(n.Expr, context)
+			if err != nil {
+				return err
 			}
 
-			for _, elseIf := range n.ElseIfs {
-				elifCondition, err := exprToValue(elseIf.Tag.Expr, context)
-				if err != nil {
-					return nil, err
-				}
+			if !condition.AsBoolean() {
+				return ErrSkipFile
+			}
 
-				if elifCondition.AsBoolean() {
-					elifContent, err := render(elseIf.Body, context)
-					if err != nil {
-						return nil, err
-					}
+		case *parser.ExtendNode:
+			return ErrExtendNotTopLevel
 
-					buf.Write(elifContent)
+		case *parser.BlockNode:
+			b := block{body: n.Body, template: s.template}
+			if override, ok := s.blocks[n.BlockTag.Name.Name]; ok {
+				b = override
+			}
 
-					return buf.Bytes(), nil
-				}
+			if err := s.renderBlock(w, b, context.Child()); err != nil {
+				return err
 			}
 
-			elseContent, err := render(n.Else.Body, context)
-			if err != nil {
-				return nil, err
+		case *parser.ForNode:
+			if err := s.renderFor(w, n, context); err != nil {
+				return err
 			}
 
-			buf.Write(elseContent)
+		default:
+			return fmt.Errorf("unexpected node type in ast: %T", n)
+		}
+	}
 
-		case *parser.SwitchNode:
-			switchValue, err := exprToValue(n.SwitchTag.Expr, context)
-			if err != nil {
-				return nil, err
-			}
+	return nil
+}
 
-			caseMatched := false
+func (s *state) renderIf(w io.Writer, n *parser.IfNode, context *Context) error {
+	conditionValue, err := s.exprToValue(n.IfTag.Expr, context)
+	if err != nil {
+		return err
+	}
 
-			for _, c := range n.Cases {
-				val, err := exprToValue(c.Tag.Expr, context)
-				if err != nil {
-					return nil, err
-				}
+	if conditionValue.AsBoolean() {
+		return s.render(w, n.Main, context.Child())
+	}
 
-				if eql(switchValue, val) {
-					body, err := render(c.Body, context)
-					if err != nil {
-						return nil, err
-					}
+	for _, elseIf := range n.ElseIfs {
+		elifCondition, err := s.exprToValue(elseIf.Tag.Expr, context)
+		if err != nil {
+			return err
+		}
 
-					buf.Write(body)
+		if elifCondition.AsBoolean() {
+			return s.render(w, elseIf.Body, context.Child())
+		}
+	}
 
-					caseMatched = true
+	return s.render(w, n.Else.Body, context.Child())
+}
 
-					break
-				}
-			}
+func (s *state) renderSwitch(w io.Writer, n *parser.SwitchNode, context *Context) error {
+	switchValue, err := s.exprToValue(n.SwitchTag.Expr, context)
+	if err != nil {
+		return err
+	}
 
-			if !caseMatched && n.DefaultCase != nil {
-				body, err := render(n.DefaultCase.Body, context)
-				if err != nil {
-					return nil, err
-				}
+	for _, c := range n.Cases {
+		val, err := s.exprToValue(c.Tag.Expr, context)
+		if err != nil {
+			return err
+		}
 
-				buf.Write(body)
-			}
+		if eql(switchValue, val) {
+			return s.render(w, c.Body, context.Child())
+		}
+	}
 
-		default:
-			return nil, fmt.Errorf("unexpected node type in ast: %T", n)
+	if n.DefaultCase != nil {
+		return s.render(w, n.DefaultCase.Body, context.Child())
+	}
+
+	return nil
+}
+
+func (s *state) renderFor(w io.Writer, n *parser.ForNode, context *Context) error {
+	collection, err := s.exprToValue(n.ForTag.Expr, context)
+	if err != nil {
+		return err
+	}
+
+	list, ok := collection.(value.ListValue)
+	if !ok {
+		return s.errorAt(n.ForTag.Expr.Pos(), fmt.Errorf("cannot iterate over %s", collection.Type()))
+	}
+
+	if len(list) == 0 {
+		return s.render(w, n.Else.Body, context.Child())
+	}
+
+	for i, item := range list {
+		if err := s.step(); err != nil {
+			return err
+		}
+
+		loopContext := context.Child()
+
+		if n.ForTag.Key != nil {
+			loopContext.Set(n.ForTag.Key.Name, value.NumberValue(i))
 		}
+
+		loopContext.Set(n.ForTag.Value.Name, item)
+
+		if err := s.render(w, n.Body, loopContext); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// text returns the text of the node with whitespace trimmed by adjacent tags.
+func text(n *parser.TextNode) string {
+	s := strings.Join(n.Val, "")
+
+	if n.TrimLeft {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+
+	if n.TrimRight {
+		s = strings.TrimRightFunc(s, unicode.IsSpace)
 	}
 
-	return buf.Bytes(), nil
+	return s
+}
+
+// rawText returns the text of the raw statement without lines of its tags,
+// the same way they are stripped around other statements.
+func rawText(n *parser.RawNode) string {
+	s := n.Val
+
+	// Rest of the line of the opening tag
+	if rest := strings.TrimLeft(s, " \t"); strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+		_, s, _ = strings.Cut(rest, "\n")
+	}
+
+	// Indentation of the end tag
+	if i := strings.LastIndexByte(s, '\n'); i != -1 && strings.TrimLeft(s[i+1:], " \t") == "" {
+		s = s[:i+1]
+	}
+
+	if n.RawTag.TrimRight {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	}
+
+	if n.EndTag.TrimLeft {
+		s = strings.TrimRightFunc(s, unicode.IsSpace)
+	}
+
+	return s
+}
+
+func getField(x value.Valuable, name string) (value.Valuable, error) {
+	m, ok := x.(value.MapValue)
+	if !ok {
+		return nil, fmt.Errorf("cannot access field %s of %s", name, x.Type())
+	}
+
+	field, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("field %s not found", name)
+	}
+
+	return field, nil
+}
+
+// getElem returns element of the list by index, negative index counts from the end.
+func getElem(list value.ListValue, index value.Valuable) (value.Valuable, error) {
+	if _, ok := index.(value.NumberValue); !ok {
+		return nil, fmt.Errorf("list index must be a number, got %s", index.Type())
+	}
+
+	f := index.AsNumber()
+	if math.Trunc(f) != f {
+		return nil, fmt.Errorf("list index %s is not an integer", index.AsString())
+	}
+
+	// Range is checked before the conversion, so huge indices don't overflow
+	if f < 0 {
+		f += float64(len(list))
+	}
+
+	if f < 0 || f >= float64(len(list)) {
+		return nil, fmt.Errorf("index %s out of range of list with length %d", index.AsString(), len(list))
+	}
+
+	return list[int(f)], nil
 }
 
 func eql(x, y value.Valuable) bool {
 	return x.AsString() == y.AsString()
 }
 
-func exprToValue(expr parser.Expr, context Context) (value.Valuable, error) {
+// exprToValue evaluates the expression, errors are positioned
+// at the innermost expression they occurred in.
+func (s *state) exprToValue(expr parser.Expr, context *Context) (value.Valuable, error) {
+	v, err := s.evalExpr(expr, context)
+	if err != nil {
+		return nil, s.errorAt(expr.Pos(), err)
+	}
+
+	return v, nil
+}
+
+func (s *state) evalExpr(expr parser.Expr, context *Context) (value.Valuable, error) {
+	if err := s.step(); err != nil {
+		return nil, err
+	}
+
 	switch n := expr.(type) {
 	case *parser.Ident:
-		value, exists := context[n.Name]
+		value, exists, err := context.Get(n.Name)
+		if err != nil {
+			return nil, err
+		}
+
 		if !exists {
 			return nil, fmt.Errorf("%s not declared", n.Name)
 		}
@@ -148,7 +375,7 @@ func exprToValue(expr parser.Expr, context Context) (value.Valuable, error) {
 		return value, nil
 
 	case *parser.TernaryExpr:
-		conditionValue, err := exprToValue(n.Condition, context)
+		conditionValue, err := s.exprToValue(n.Condition, context)
 		if err != nil {
 			return nil, err
 		}
@@ -160,7 +387,7 @@ func exprToValue(expr parser.Expr, context Context) (value.Valuable, error) {
 			exp = n.FalseExpr
 		}
 
-		value, err := exprToValue(exp, context)
+		value, err := s.exprToValue(exp, context)
 		if err != nil {
 			return nil, err
 		}
@@ -168,7 +395,7 @@ func exprToValue(expr parser.Expr, context Context) (value.Valuable, error) {
 		return value, nil
 
 	case *parser.UnaryExpr:
-		v, err := exprToValue(n.Expr, context)
+		v, err := s.exprToValue(n.Expr, context)
 		if err != nil {
 			return nil, err
 		}
@@ -177,6 +404,9 @@ func exprToValue(expr parser.Expr, context Context) (value.Valuable, error) {
 		case token.EXCL, token.NOT:
 			return value.BooleanValue(!v.AsBoolean()), nil
 
+		case token.MINUS:
+			return value.NumberValue(-v.AsNumber()), nil
+
 		default:
 			return nil, errors.New("unknown operator in unary expression")
 		}
@@ -187,31 +417,115 @@ func exprToValue(expr parser.Expr, context Context) (value.Valuable, error) {
 	case *parser.StringLit:
 		return n.Value, nil
 
+	case *parser.InterpolatedLit:
+		var b strings.Builder
+
+		for _, part := range n.Parts {
+			v, err := s.exprToValue(part, context)
+			if err != nil {
+				return nil, err
+			}
+
+			b.WriteString(v.AsString())
+		}
+
+		return value.StringValue(b.String()), nil
+
 	case *parser.FilterExpr:
-		expr, err := exprToValue(n.Expr, context)
+		expr, err := s.exprToValue(n.Expr, context)
 		if err != nil {
 			return nil, err
 		}
 
-		return callFilter(n.Filter.Name, expr)
+		args := make([]value.Valuable, len(n.Args))
+		for i, arg := range n.Args {
+			if args[i], err = s.exprToValue(arg, context); err != nil {
+				return nil, err
+			}
+		}
+
+		v, err := s.callFilter(n.Filter.Name, expr, args)
+		if err != nil {
+			return nil, s.errorAt(n.Filter.Pos(), err)
+		}
+
+		return v, nil
 
 	case *parser.ParenExpr:
-		return exprToValue(n.Expr, context)
+		return s.exprToValue(n.Expr, context)
+
+	case *parser.SelectorExpr:
+		x, err := s.exprToValue(n.X, context)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := getField(x, n.Sel.Name)
+		if err != nil {
+			return nil, s.errorAt(n.Sel.Pos(), err)
+		}
+
+		return v, nil
+
+	case *parser.IndexExpr:
+		x, err := s.exprToValue(n.X, context)
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := s.exprToValue(n.Index, context)
+		if err != nil {
+			return nil, err
+		}
+
+		var v value.Valuable
+		if list, ok := x.(value.ListValue); ok {
+			v, err = getElem(list, index)
+		} else {
+			v, err = getField(x, index.AsString())
+		}
+
+		if err != nil {
+			return nil, s.errorAt(n.Index.Pos(), err)
+		}
+
+		return v, nil
 
 	case *parser.BinaryExpr:
-		x, err := exprToValue(n.X, context)
+		x, err := s.exprToValue(n.X, context)
 		if err != nil {
 			return nil, err
 		}
 
-		y, err := exprToValue(n.Y, context)
+		y, err := s.exprToValue(n.Y, context)
 		if err != nil {
 			return nil, err
 		}
 
 		switch n.Op.Kind {
-		// case token.ADD:
-		// 	return x.Add(y), nil
+		case token.ADD:
+			return x.Add(y), nil
+
+		case token.MINUS:
+			return value.NumberValue(x.AsNumber() - y.AsNumber()), nil
+
+		case token.MUL:
+			return value.NumberValue(x.AsNumber() * y.AsNumber()), nil
+
+		case token.DIV:
+			if y.AsNumber() == 0 {
+				return nil, s.errorAt(n.Op.Pos, ErrDivisionByZero)
+			}
+
+			return value.NumberValue(x.AsNumber() / y.AsNumber()), nil
+
+		case token.MOD:
+			if y.AsNumber() == 0 {
+				return nil, s.errorAt(n.Op.Pos, ErrDivisionByZero)
+			}
+
+			return value.NumberValue(math.Mod(x.AsNumber(), y.AsNumber())), nil
+
 		case token.NEQL, token.ISNOT:
 			return value.BooleanValue(x.AsString() != y.AsString()), nil
 
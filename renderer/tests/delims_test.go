@@ -0,0 +1,59 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func TestCustomDelims(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:     "Comment is not rendered",
+			input:    "a{# comment #}b",
+			expected: "ab",
+		},
+		{
+			name: "Delimiters of the environment",
+			input: `
+{{ .Values.name }}: [[ name ]]
+<% if enabled %>
+enabled: true
+<% end %>
+`[1:],
+			scope:  renderer.Input{"name": "flow", "enabled": true},
+			delims: lexer.Delims{LExpr: "[[", RExpr: "]]", LStmt: "<%", RStmt: "%>"},
+			expected: `
+{{ .Values.name }}: flow
+enabled: true
+`[1:],
+		},
+		{
+			name: "Delimiters of the pragma",
+			input: `
+{# flow:delims <%= %> <% %> <%# %> #}
+<%# Greeting %>
+Hello, <%= name -> upper %>!
+<% for i in items %>{{ i }}<%= i %> <% end %>
+`[1:],
+			scope:    renderer.Input{"name": "flow", "items": []any{1, 2}},
+			expected: "Hello, FLOW!\n{{ i }}1 {{ i }}2 ",
+		},
+		{
+			name:      "Loaded templates use delimiters of the environment",
+			input:     "[% extend \"base\" %]\n[% block title %][[ name ]][% end %]",
+			templates: renderer.MapLoader{"base": "<title>[% block title %][% end %]</title>"},
+			scope:     renderer.Input{"name": "flow"},
+			delims:    lexer.Delims{LExpr: "[[", RExpr: "]]", LStmt: "[%", RStmt: "%]"},
+			expected:  "<title>flow</title>",
+		},
+		{
+			name:        "Invalid delimiters",
+			input:       "{{ a }}",
+			delims:      lexer.Delims{LStmt: "{{"},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/types"
+	"github.com/flowtemplates/flow-go/value"
 )
 
 func TestExpressions(t *testing.T) {
@@ -44,44 +46,6 @@ func TestExpressions(t *testing.T) {
 			expected: "word",
 			scope:    renderer.Input{},
 		},
-		// {
-		// 	name:     "Addition",
-		// 	str:      "{{123+2}}",
-		// 	expected: "125",
-		// 	input: []parser.Node{
-		// 		parser.ExprBlock{
-		// 			Body: parser.BinaryExpr{
-		// 				X: parser.Lit{
-		// 					Value: value.NumberValue(123),
-		// 				},
-		// 				Op: token.ADD,
-		// 				Y: parser.Lit{
-		// 					Value: value.NumberValue(2),
-		// 				},
-		// 			},
-		// 		},
-		// 	},
-		// 	scope:       renderer.Scope{},
-		// },
-		// {
-		// 	name:     "Subtraction",
-		// 	str:      "{{123-10}}",
-		// 	expected: "113",
-		// 	input: []parser.Node{
-		// 		parser.ExprBlock{
-		// 			Body: parser.BinaryExpr{
-		// 				X: parser.Lit{
-		// 					Value: value.NumberValue(123),
-		// 				},
-		// 				Op: token.SUB,
-		// 				Y: parser.Lit{
-		// 					Value: value.NumberValue(10),
-		// 				},
-		// 			},
-		// 		},
-		// 	},
-		// 	scope:       renderer.Scope{},
-		// },
 		{
 			name:     "Expression with string var",
 			input:    "{{name}}",
@@ -185,6 +149,260 @@ func TestOperators(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+func TestArithmetic(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:     "Addition",
+			input:    "{{123+2}}",
+			expected: "125",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Subtraction",
+			input:    "{{123-10}}",
+			expected: "113",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Precedence",
+			input:    "{{ 1 + 2 * 3 - 4 / 2 }}",
+			expected: "5",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Parens changing precedence",
+			input:    "{{ (1 + 2) * 3 }}",
+			expected: "9",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Subtraction is left-associative",
+			input:    "{{ 10 - 2 - 3 }}",
+			expected: "5",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Modulo",
+			input:    "{{ 7 % 3 }}",
+			expected: "1",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Float division",
+			input:    "{{ 7 / 2 }}",
+			expected: "3.5",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Var plus number",
+			input:    "{{ index + 1 }}",
+			expected: "3",
+			scope: renderer.Input{
+				"index": 2,
+			},
+		},
+		{
+			name:     "Negation of var",
+			input:    "{{ -index }}",
+			expected: "-2",
+			scope: renderer.Input{
+				"index": 2,
+			},
+		},
+		{
+			name:     "String concatenation",
+			input:    "{{ prefix + name }}",
+			expected: "get_name",
+			scope: renderer.Input{
+				"prefix": "get_",
+				"name":   "name",
+			},
+		},
+		{
+			name:     "String concatenation with number",
+			input:    "{{ 'v' + 2 }}",
+			expected: "v2",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Arithmetic in comparison",
+			input:    "{% if a + 1 == 3 %}yes{% end %}",
+			expected: "yes",
+			scope: renderer.Input{
+				"a": 2,
+			},
+		},
+		{
+			name:        "Division by zero",
+			input:       "{{ 1 / 0 }}",
+			scope:       renderer.Input{},
+			errExpected: true,
+		},
+		{
+			name:        "Modulo by zero",
+			input:       "{{ 1 % 0 }}",
+			scope:       renderer.Input{},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+type user struct {
+	Name    string
+	Tags    []string
+	Address *address `json:"address"`
+	secret  string
+}
+
+func TestMemberAccess(t *testing.T) {
+	scope := renderer.Input{
+		"user": user{
+			Name:    "Bob",
+			Tags:    []string{"admin", "dev"},
+			Address: &address{City: "Berlin"},
+			secret:  "secret",
+		},
+		"config": map[string]any{
+			"key": "value",
+			"nested": map[string]any{
+				"list": []int{1, 2, 3},
+			},
+		},
+		"items": []map[string]string{
+			{"name": "first"},
+		},
+	}
+
+	cyclic := map[string]any{}
+	cyclic["self"] = cyclic
+
+	shared := []int{1, 2}
+
+	testCases := []testCase{
+		{
+			name:     "Map field",
+			input:    "{{ config.key }}",
+			expected: "value",
+			scope:    scope,
+		},
+		{
+			name:     "Map index",
+			input:    `{{ config["key"] }}`,
+			expected: "value",
+			scope:    scope,
+		},
+		{
+			name:     "Nested map and list",
+			input:    "{{ config.nested.list[1] }}",
+			expected: "2",
+			scope:    scope,
+		},
+		{
+			name:     "Negative list index",
+			input:    "{{ config.nested.list[-1] }}",
+			expected: "3",
+			scope:    scope,
+		},
+		{
+			name:     "Computed index",
+			input:    "{{ config.nested.list[a + 1] }}",
+			expected: "3",
+			scope: renderer.Input{
+				"a":      1,
+				"config": scope["config"],
+			},
+		},
+		{
+			name:     "Struct fields",
+			input:    "{{ user.Name }} {{ user.Tags[0] }} {{ user.address.city }}",
+			expected: "Bob admin Berlin",
+			scope:    scope,
+		},
+		{
+			name:     "List of maps with filter",
+			input:    "{{ items[0].name -> upper }}",
+			expected: "FIRST",
+			scope:    scope,
+		},
+		{
+			name:     "Member access in loop",
+			input:    "{% for tag in user.Tags %}{{ tag }};{% end %}",
+			expected: "admin;dev;",
+			scope:    scope,
+		},
+		{
+			name:        "Unexported struct field",
+			input:       "{{ user.secret }}",
+			scope:       scope,
+			errExpected: true,
+		},
+		{
+			name:        "Missing field",
+			input:       "{{ config.missing }}",
+			scope:       scope,
+			errExpected: true,
+		},
+		{
+			name:        "Index out of range",
+			input:       "{{ items[1] }}",
+			scope:       scope,
+			errExpected: true,
+		},
+		{
+			name:        "Fractional index",
+			input:       "{{ config.nested.list[1.5] }}",
+			scope:       scope,
+			errExpected: true,
+		},
+		{
+			name:        "Huge index",
+			input:       "{{ config.nested.list[100000000000000000000000] }}",
+			scope:       scope,
+			errExpected: true,
+		},
+		{
+			name:        "Field of string",
+			input:       "{{ user.Name.first }}",
+			scope:       scope,
+			errExpected: true,
+		},
+		{
+			name:     "Map with non-string keys",
+			input:    `{{ m["1"] }}{{ m.true }}`,
+			expected: "ab",
+			scope: renderer.Input{
+				"m": map[any]string{1: "a", true: "b"},
+			},
+		},
+		{
+			name:     "Shared values",
+			input:    "{{ m.a[1] }}{{ m.b[0] }}",
+			expected: "21",
+			scope: renderer.Input{
+				"m": map[string]any{"a": shared, "b": shared},
+			},
+		},
+		{
+			name:        "Value referencing itself",
+			input:       "{{ m }}",
+			scope:       renderer.Input{"m": cyclic},
+			errExpected: true,
+		},
+		{
+			name:        "Function value",
+			input:       "{{ f }}",
+			scope:       renderer.Input{"f": func() {}},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func TestTernaries(t *testing.T) {
 	testCases := []testCase{
 		{
@@ -275,6 +493,57 @@ func TestTernaries(t *testing.T) {
 	runTestCases(t, testCases)
 }
 
+func TestStringLiterals(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:     "Escape sequences",
+			input:    `{{ "a\tb\n\"c\" caf\u00e9 \\" }}`,
+			expected: "a\tb\n\"c\" café \\",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Escaped quote in single quotes",
+			input:    `{{ 'it\'s' }}`,
+			expected: "it's",
+			scope:    renderer.Input{},
+		},
+		{
+			name:     "Raw string",
+			input:    "{{ `C:\\dir\\${name}` }}",
+			expected: `C:\dir\${name}`,
+			scope: renderer.Input{
+				"name": "Alice",
+			},
+		},
+		{
+			name:     "Interpolation",
+			input:    `{{ "Hello, ${ name -> upper }! You are ${age + 1}." }}`,
+			expected: "Hello, ALICE! You are 31.",
+			scope: renderer.Input{
+				"name": "Alice",
+				"age":  30,
+			},
+		},
+		{
+			name:     "Interpolation with nested string",
+			input:    `{{ "${ items -> join(", ") }" -> upper }}`,
+			expected: "A, B",
+			scope: renderer.Input{
+				"items": []string{"a", "b"},
+			},
+		},
+		{
+			name:     "Escaped interpolation",
+			input:    `{{ "\${name}" }}`,
+			expected: "${name}",
+			scope: renderer.Input{
+				"name": "Alice",
+			},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
 func TestFilters(t *testing.T) {
 	testCases := []testCase{
 		{
@@ -371,6 +640,14 @@ func TestFilters(t *testing.T) {
 				"s": "hello world",
 			},
 		},
+		{
+			name:     "Capitalize non-ASCII",
+			input:    "{{ s -> capitalize }}",
+			expected: "Élan Привет",
+			scope: renderer.Input{
+				"s": "élan Привет",
+			},
+		},
 		{
 			name:     "Trim",
 			input:    "{{ s -> trim }}",
@@ -387,6 +664,14 @@ func TestFilters(t *testing.T) {
 				"s": "Hello world",
 			},
 		},
+		{
+			name:     "Non-ASCII string length",
+			input:    "{{ s -> length }}",
+			expected: "5",
+			scope: renderer.Input{
+				"s": "日本語🙋é",
+			},
+		},
 		{
 			name:     "Number length",
 			input:    "{{ 123 -> length }}",
@@ -407,6 +692,96 @@ func TestFilters(t *testing.T) {
 				"length": "huh",
 			},
 		},
+		{
+			name:     "Replace",
+			input:    `{{ name -> replace("-", "_") }}`,
+			expected: "user_id_x",
+			scope: renderer.Input{
+				"name": "user-id-x",
+			},
+		},
+		{
+			name:     "Truncate",
+			input:    "{{ s -> truncate(n + 1) }}",
+			expected: "привет",
+			scope: renderer.Input{
+				"s": "привет мир",
+				"n": 5,
+			},
+		},
+		{
+			name:     "Join",
+			input:    `{{ items -> join(", ") -> upper }}`,
+			expected: "A, B",
+			scope: renderer.Input{
+				"items": []string{"a", "b"},
+			},
+		},
+		{
+			name:        "Join not a list",
+			input:       `{{ s -> join(", ") }}`,
+			errExpected: true,
+			scope: renderer.Input{
+				"s": "a",
+			},
+		},
+		{
+			name:        "Wrong number of arguments",
+			input:       "{{ s -> truncate }}",
+			errExpected: true,
+			scope: renderer.Input{
+				"s": "a",
+			},
+		},
+		{
+			name:     "Length of list",
+			input:    "{{ items -> length }}",
+			expected: "3",
+			scope: renderer.Input{
+				"items": []int{1, 2, 3},
+			},
+		},
+		{
+			name:     "Capitalize empty string",
+			input:    "{{ '' -> capitalize }}",
+			expected: "",
+			scope:    renderer.Input{},
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestCustomFilters(t *testing.T) {
+	filters := renderer.DefaultFilters()
+	filters["plural"] = renderer.Filter{
+		Input:  types.String,
+		Output: types.String,
+		Params: []types.Type{types.Number},
+		Func: func(v value.Valuable, args []value.Valuable) (value.Valuable, error) {
+			if args[0].AsNumber() == 1 {
+				return v, nil
+			}
+
+			return value.StringValue(v.AsString() + "s"), nil
+		},
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Custom filter",
+			input:    "{{ n }} {{ 'file' -> plural(n) -> upper }}",
+			expected: "2 FILES",
+			scope: renderer.Input{
+				"n": 2,
+			},
+			filters: filters,
+		},
+		{
+			name:        "Custom filter is not global",
+			input:       "{{ 'file' -> plural(1) }}",
+			errExpected: true,
+			scope:       renderer.Input{},
+		},
 	}
 	runTestCases(t, testCases)
 }
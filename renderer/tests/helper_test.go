@@ -3,6 +3,7 @@ package renderer_test
 import (
 	"testing"
 
+	"github.com/flowtemplates/flow-go/lexer"
 	"github.com/flowtemplates/flow-go/renderer"
 )
 
@@ -10,6 +11,9 @@ type testCase struct {
 	name        string
 	input       string
 	scope       renderer.Input
+	templates   renderer.MapLoader
+	filters     renderer.Filters
+	delims      lexer.Delims
 	expected    string
 	errExpected bool
 }
@@ -19,7 +23,15 @@ func runTestCases(t *testing.T, testCases []testCase) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := renderer.RenderBytes([]byte(tc.input), tc.scope)
+			env := renderer.Environment{
+				Filters: tc.filters,
+				Delims:  tc.delims,
+			}
+			if tc.templates != nil {
+				env.Loader = tc.templates
+			}
+
+			got, err := env.RenderBytes([]byte(tc.input), tc.scope)
 			if (err != nil) != tc.errExpected {
 				t.Errorf("Input: %q\nUnexpected error: %v", tc.input, err)
 
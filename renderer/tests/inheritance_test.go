@@ -0,0 +1,246 @@
+package renderer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func TestInheritance(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Block without extend",
+			input: `
+{% block header %}
+Header
+{% end %}
+Body
+`[1:],
+			expected: `
+Header
+Body
+`[1:],
+			scope: renderer.Input{},
+		},
+		{
+			name: "Extend with overridden block",
+			input: `
+{% extend "base.flow" %}
+{% block body %}
+Child {{ name }}
+{% end %}
+`[1:],
+			expected: `
+// Code generated by flow. DO NOT EDIT.
+Child foo
+`[1:],
+			scope: renderer.Input{
+				"name": "foo",
+			},
+			templates: renderer.MapLoader{
+				"base.flow": `
+// Code generated by flow. DO NOT EDIT.
+{% block body %}
+Base
+{% end %}
+`[1:],
+			},
+		},
+		{
+			name: "Extend without overriding",
+			input: `
+{% extend "base.flow" %}
+`[1:],
+			expected: `
+Header
+Base
+`[1:],
+			scope: renderer.Input{},
+			templates: renderer.MapLoader{
+				"base.flow": `
+{% block header %}
+Header
+{% end %}
+{% block body %}
+Base
+{% end %}
+`[1:],
+			},
+		},
+		{
+			name: "Text outside of blocks in child is ignored",
+			input: `
+{% extend "base.flow" %}
+ignored
+{% block body %}
+Child
+{% end %}
+`[1:],
+			expected: `
+Child
+`[1:],
+			scope: renderer.Input{},
+			templates: renderer.MapLoader{
+				"base.flow": `
+{% block body %}
+Base
+{% end %}
+`[1:],
+			},
+		},
+		{
+			name: "Multilevel extend",
+			input: `
+{% extend "layout.flow" %}
+{% block title %}
+Child title
+{% end %}
+`[1:],
+			expected: `
+Child title
+Layout body
+`[1:],
+			scope: renderer.Input{},
+			templates: renderer.MapLoader{
+				"layout.flow": `
+{% extend "base.flow" %}
+{% block body %}
+Layout body
+{% end %}
+`[1:],
+				"base.flow": `
+{% block title %}
+Base title
+{% end %}
+{% block body %}
+Base body
+{% end %}
+`[1:],
+			},
+		},
+		{
+			name: "Nested blocks",
+			input: `
+{% extend "base.flow" %}
+{% block inner %}
+Child inner
+{% end %}
+`[1:],
+			expected: `
+Outer
+Child inner
+`[1:],
+			scope: renderer.Input{},
+			templates: renderer.MapLoader{
+				"base.flow": `
+{% block outer %}
+Outer
+{% block inner %}
+Base inner
+{% end %}
+{% end %}
+`[1:],
+			},
+		},
+		{
+			name:        "Extend without loader",
+			input:       `{% extend "base.flow" %}`,
+			scope:       renderer.Input{},
+			errExpected: true,
+		},
+		{
+			name:  "Extend not existing template",
+			input: `{% extend "base.flow" %}`,
+			scope: renderer.Input{},
+			templates: renderer.MapLoader{
+				"other.flow": "",
+			},
+			errExpected: true,
+		},
+		{
+			name:  "Cyclic extend",
+			input: `{% extend "a.flow" %}`,
+			scope: renderer.Input{},
+			templates: renderer.MapLoader{
+				"a.flow": `{% extend "b.flow" %}`,
+				"b.flow": `{% extend "a.flow" %}`,
+			},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestTemplateErrors(t *testing.T) {
+	templates := renderer.MapLoader{
+		"base.flow":   `{% extend "layout.flow" %}{% block body %}{{ b }}{% end %}`,
+		"layout.flow": "{% genif g %}\n{% block title %}{{ l }}{% end %}{% block body %}{% end %}",
+		"broken.flow": "{{ a b }}",
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		scope    renderer.Input
+		template string
+		offset   int
+	}{
+		{
+			name:     "Parse error in extended template",
+			input:    `{% extend "broken.flow" %}`,
+			template: "broken.flow",
+		},
+		{
+			name:     "Error in block of parent",
+			input:    `{% extend "base.flow" %}`,
+			scope:    renderer.Input{"g": true, "l": 1},
+			template: "base.flow",
+			offset:   45,
+		},
+		{
+			name:     "Error in block of grandparent",
+			input:    `{% extend "base.flow" %}{% block body %}{% end %}`,
+			scope:    renderer.Input{"g": true},
+			template: "layout.flow",
+			offset:   34,
+		},
+		{
+			name:     "Error in genif of grandparent",
+			input:    `{% extend "base.flow" %}`,
+			template: "layout.flow",
+			offset:   9,
+		},
+		{
+			name:   "Error in overriding block",
+			input:  `{% extend "base.flow" %}{% block body %}{{ c }}{% end %}`,
+			scope:  renderer.Input{"g": true, "l": 1},
+			offset: 43,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := renderer.Environment{Loader: templates}
+
+			_, err := env.RenderBytes([]byte(tc.input), tc.scope)
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+
+			var tmplErr renderer.TemplateError
+			if ok := errors.As(err, &tmplErr); ok != (tc.template != "") {
+				t.Fatalf("Input: %q\nUnexpected TemplateError: %v", tc.input, err)
+			}
+
+			if tmplErr.Name != tc.template || string(tmplErr.Source) != templates[tc.template] {
+				t.Errorf("Input: %q\nExpected template %q, got %q", tc.input, tc.template, tmplErr.Name)
+			}
+
+			var posErr renderer.Error
+			if tc.offset != 0 && (!errors.As(err, &posErr) || posErr.Pos.Offset != tc.offset) {
+				t.Errorf("Input: %q\nExpected error at %d, got %v", tc.input, tc.offset, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package renderer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func TestLimits(t *testing.T) {
+	items := make([]any, 1000)
+	for i := range items {
+		items[i] = i
+	}
+
+	testCases := []struct {
+		name      string
+		input     string
+		limits    renderer.Limits
+		templates renderer.MapLoader
+		errLimit  error
+		max       int64
+		expected  string
+	}{
+		{
+			name:     "Output within limit",
+			input:    "Hello {{ name }}",
+			limits:   renderer.Limits{MaxOutputBytes: 10},
+			expected: "Hello flow",
+		},
+		{
+			name:     "Output exceeds limit",
+			input:    "Hello {{ name }}!",
+			limits:   renderer.Limits{MaxOutputBytes: 8},
+			errLimit: renderer.ErrOutputLimit,
+			max:      8,
+			expected: "Hello fl",
+		},
+		{
+			name:     "Steps within limit",
+			input:    "{% for i in items %}{% end %}",
+			limits:   renderer.Limits{MaxSteps: 1002},
+			expected: "",
+		},
+		{
+			name:     "Steps exceed limit",
+			input:    "{% for i in items %}{{ i }}{% end %}",
+			limits:   renderer.Limits{MaxSteps: 100},
+			errLimit: renderer.ErrStepLimit,
+			max:      100,
+			// Every iteration takes a step for itself, its node and its expression
+			expected: "012345678910111213141516171819202122232425262728293031",
+		},
+		{
+			name:  "Extend within depth limit",
+			input: "{% extend \"base\" %}\n{% block b %}child{% end %}",
+			templates: renderer.MapLoader{
+				"base": `{% block b %}base{% end %}`,
+			},
+			limits:   renderer.Limits{MaxIncludeDepth: 1},
+			expected: "child",
+		},
+		{
+			name:  "Extend exceeds depth limit",
+			input: `{% extend "a" %}`,
+			templates: renderer.MapLoader{
+				"a": `{% extend "b" %}`,
+				"b": `{% extend "c" %}`,
+				"c": `base`,
+			},
+			limits:   renderer.Limits{MaxIncludeDepth: 2},
+			errLimit: renderer.ErrDepthLimit,
+			max:      2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ast, err := parser.AstFromBytes([]byte(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			env := renderer.Environment{
+				Limits: tc.limits,
+				Loader: tc.templates,
+			}
+
+			var buf bytes.Buffer
+
+			err = env.RenderContext(context.Background(), &buf, ast, renderer.Input{
+				"name":  "flow",
+				"items": items,
+			})
+
+			if tc.errLimit == nil {
+				if err != nil {
+					t.Errorf("Input: %q\nUnexpected error: %v", tc.input, err)
+				}
+			} else {
+				var limitErr *renderer.LimitError
+				if !errors.As(err, &limitErr) || !errors.Is(err, tc.errLimit) || limitErr.Limit != tc.max {
+					t.Errorf("Input: %q\nExpected %v with limit %d, got %v", tc.input, tc.errLimit, tc.max, err)
+				}
+			}
+
+			if buf.String() != tc.expected {
+				t.Errorf("Input: %q\nMismatch.\nExpected:\n%q\nGot:\n%q", tc.input, tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestRenderContextCanceled(t *testing.T) {
+	ast, err := parser.AstFromBytes([]byte("{% for i in items %}{{ i }}{% end %}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+
+	err = renderer.RenderContext(ctx, &buf, ast, renderer.Input{"items": []any{1, 2, 3}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,55 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func TestRawStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:     "Inline raw statement",
+			input:    "a {% raw %}{{ b }}{% end %}: {{ c }}",
+			scope:    renderer.Input{"c": "C"},
+			expected: "a {{ b }}: C",
+		},
+		{
+			name: "Raw statement on its own lines",
+			input: `
+name: {{ name }}
+{% raw %}
+image: {{ .Values.image }}
+{{- if .Values.enabled }}
+{% end %}
+end
+`[1:],
+			scope: renderer.Input{"name": "chart"},
+			expected: `
+name: chart
+image: {{ .Values.image }}
+{{- if .Values.enabled }}
+end
+`[1:],
+		},
+		{
+			name: "Indented raw statement",
+			input: `
+steps:
+  {% raw %}
+  - run: echo ${{ github.sha }}
+  {% end %}
+`[1:],
+			expected: `
+steps:
+  - run: echo ${{ github.sha }}
+`[1:],
+		},
+		{
+			name:     "Raw statement with trim markers",
+			input:    "a\n{%- raw -%}\n  {% x %}  \n{%- end -%}\nb",
+			expected: "a{% x %}b",
+		},
+	}
+	runTestCases(t, testCases)
+}
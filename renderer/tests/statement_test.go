@@ -1,6 +1,7 @@
 package renderer_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/flowtemplates/flow-go/renderer"
@@ -250,6 +251,256 @@ text2
 	runTestCases(t, testCases)
 }
 
+func TestForStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Simple for statement",
+			input: `
+{% for item in items %}
+- {{ item }}
+{% end %}
+`[1:],
+			expected: `
+- a
+- b
+`[1:],
+			scope: renderer.Input{
+				"items": []string{"a", "b"},
+			},
+		},
+		{
+			name: "For statement with index",
+			input: `
+{% for i, item in items %}
+{{ i }}: {{ item }}
+{% end %}
+`[1:],
+			expected: `
+0: 1
+1: 2
+`[1:],
+			scope: renderer.Input{
+				"items": []int{1, 2},
+			},
+		},
+		{
+			name: "For-else statement on empty list",
+			input: `
+{% for item in items %}
+{{ item }}
+{% else %}
+empty
+{% end %}
+`[1:],
+			expected: `
+empty
+`[1:],
+			scope: renderer.Input{
+				"items": []any{},
+			},
+		},
+		{
+			name: "For statement with if inside",
+			input: `
+{% for item in items %}
+{% if item %}
+yes
+{% end %}
+-
+{% end %}
+`[1:],
+			expected: `
+yes
+-
+-
+`[1:],
+			scope: renderer.Input{
+				"items": []bool{true, false},
+			},
+		},
+		{
+			name: "Loop variable does not leak",
+			input: `
+{% for item in items %}
+{% end %}
+{{ item }}
+`[1:],
+			scope: renderer.Input{
+				"items": []string{"a"},
+			},
+			errExpected: true,
+		},
+		{
+			name:  "For statement over not a list",
+			input: "{% for item in items %}{% end %}",
+			scope: renderer.Input{
+				"items": "abc",
+			},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestLetStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Simple let statement",
+			input: `
+{% let a = 1 %}
+{{ a }}
+`[1:],
+			expected: `
+1
+`[1:],
+			scope: renderer.Input{},
+		},
+		{
+			name: "Let statement with computed value",
+			input: `
+{% let a = name -> upper %}
+{{ a }}
+`[1:],
+			expected: `
+FOO
+`[1:],
+			scope: renderer.Input{
+				"name": "foo",
+			},
+		},
+		{
+			name: "Let statement shadows input",
+			input: `
+{% let name = "bar" %}
+{{ name }}
+`[1:],
+			expected: `
+bar
+`[1:],
+			scope: renderer.Input{
+				"name": "foo",
+			},
+		},
+		{
+			name: "Let statement inside if does not leak",
+			input: `
+{% if true %}
+{% let name = "bar" %}
+{{ name }}
+{% end %}
+{{ name }}
+`[1:],
+			expected: `
+bar
+foo
+`[1:],
+			scope: renderer.Input{
+				"name": "foo",
+			},
+		},
+		{
+			name: "Let statement inside for is bound per iteration",
+			input: `
+{% for item in items %}
+{% let upper = item -> upper %}
+{{ upper }}
+{% end %}
+`[1:],
+			expected: `
+A
+B
+`[1:],
+			scope: renderer.Input{
+				"items": []string{"a", "b"},
+			},
+		},
+		{
+			name: "Let statement inside switch does not leak",
+			input: `
+{% switch 1 %}
+{% case 1 %}
+{% let a = 1 %}
+{% end %}
+{{ a }}
+`[1:],
+			scope:       renderer.Input{},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestGenifStatements(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "Truthy genif",
+			input: `
+{% genif flag %}
+text
+`[1:],
+			expected: `
+text
+`[1:],
+			scope: renderer.Input{
+				"flag": true,
+			},
+		},
+		{
+			name: "Falsy genif",
+			input: `
+{% genif flag %}
+text
+`[1:],
+			scope: renderer.Input{
+				"flag": false,
+			},
+			errExpected: true,
+		},
+	}
+	runTestCases(t, testCases)
+}
+
+func TestGenifSkipsFile(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		templates renderer.MapLoader
+	}{
+		{
+			name:  "Falsy genif",
+			input: "{% genif 1 == 2 %}\ntext",
+		},
+		{
+			name:  "Falsy genif after text",
+			input: "text\n{% genif false %}",
+		},
+		{
+			name:  "Falsy genif in child template",
+			input: "{% genif false %}\n{% extend \"base.flow\" %}",
+			templates: renderer.MapLoader{
+				"base.flow": "text",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := renderer.Environment{
+				Loader: tc.templates,
+			}
+
+			got, err := env.RenderBytes([]byte(tc.input), renderer.Input{})
+			if !errors.Is(err, renderer.ErrSkipFile) {
+				t.Errorf("Input: %q\nExpected ErrSkipFile, got: %v", tc.input, err)
+			}
+
+			if got != nil {
+				t.Errorf("Input: %q\nExpected no output, got: %q", tc.input, got)
+			}
+		})
+	}
+}
+
 func TestComparasions(t *testing.T) {
 	testCases := []testCase{
 		{
@@ -270,6 +521,23 @@ func TestComparasions(t *testing.T) {
 			expected: "text\n",
 			scope:    renderer.Input{},
 		},
+		{
+			name:     "Variable equal string literal",
+			input:    `{%if a == "x"%}yes{%end%}`,
+			expected: "yes",
+			scope: renderer.Input{
+				"a": "x",
+			},
+		},
+		{
+			name:     "Variables is not",
+			input:    "{%if a is not b%}\ntext\n{%end%}",
+			expected: "text\n",
+			scope: renderer.Input{
+				"a": 1,
+				"b": 2,
+			},
+		},
 		{
 			name:     "String literals not equal",
 			input:    "{%if 'a' != 'b'%}\ntext\n{%end%}",
@@ -0,0 +1,104 @@
+package renderer_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+// limitedWriter fails after n bytes are written.
+type limitedWriter struct {
+	n      int
+	writes int
+}
+
+var errWriterFull = errors.New("writer is full")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.writes++
+
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+
+		return written, errWriterFull
+	}
+
+	w.n -= len(p)
+
+	return len(p), nil
+}
+
+func TestRenderStreams(t *testing.T) {
+	src := "{% for i, item in items %}{% if i > 0 %}, {% end %}{{ item }}{% end %};"
+
+	ast, err := parser.AstFromBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := make([]any, 1000)
+	for i := range items {
+		items[i] = "x"
+	}
+
+	scope := renderer.Input{"items": items}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, ast, scope); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Repeat("x, ", 999) + "x;"
+	if buf.String() != expected {
+		t.Errorf("Mismatch.\nExpected:\n%q\nGot:\n%q", expected, buf.String())
+	}
+
+	w := &limitedWriter{n: 10}
+
+	err = renderer.Render(w, ast, scope)
+	if !errors.Is(err, errWriterFull) {
+		t.Errorf("Expected writer error, got %v", err)
+	}
+
+	// Rendering stops at the first failed write
+	if w.writes != 8 {
+		t.Errorf("Expected rendering to stop after 8 writes, got %d", w.writes)
+	}
+}
+
+func TestRenderMatchesRenderBytes(t *testing.T) {
+	src := `
+{% let n = name -> upper %}
+{% switch kind %}
+{% case "a" %}
+A {{ n }}
+{% default %}
+?
+{% end %}
+`[1:]
+	scope := renderer.Input{"name": "flow", "kind": "a"}
+
+	expected, err := renderer.RenderBytes([]byte(src), scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ast, err := parser.AstFromBytes([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, ast, scope); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != string(expected) {
+		t.Errorf("Mismatch.\nExpected:\n%q\nGot:\n%q", expected, buf.String())
+	}
+}
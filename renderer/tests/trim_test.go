@@ -0,0 +1,60 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/flowtemplates/flow-go/renderer"
+)
+
+func TestTrimMarkers(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:     "Expression trims both sides",
+			input:    "a  \n {{- x -}} \n  b",
+			scope:    renderer.Input{"x": 1},
+			expected: "a1b",
+		},
+		{
+			name:     "Expression trims left side",
+			input:    "a {{- x }} b",
+			scope:    renderer.Input{"x": 1},
+			expected: "a1 b",
+		},
+		{
+			name:     "Negative number is not trimmed",
+			input:    "a {{-1}} b",
+			expected: "a -1 b",
+		},
+		{
+			name:     "Inline if joins lines",
+			input:    "list:\n  {%- if x -%}\n  item\n{%- end %}\n\nend",
+			scope:    renderer.Input{"x": true},
+			expected: "list:item\nend",
+		},
+		{
+			name: "Loop on a single line",
+			input: `
+values: [
+{%- for i, v in items -%}
+  {%- if i > 0 %}, {% end -%}
+  {{ v }}
+{%- end -%}
+]
+`[1:],
+			scope:    renderer.Input{"items": []any{1, 2, 3}},
+			expected: "values: [1, 2, 3]\n",
+		},
+		{
+			name:     "Else branch",
+			input:    "{% if x %}a{% else -%}\n   b   \n{%- end %}!",
+			scope:    renderer.Input{"x": false},
+			expected: "b!",
+		},
+		{
+			name:     "Trim after string in statement",
+			input:    "{% let a = \"x\" -%}\n\n{{ a }}",
+			expected: "x",
+		},
+	}
+	runTestCases(t, testCases)
+}
@@ -0,0 +1,308 @@
+package flow_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+
+	flow "github.com/flowtemplates/flow-go"
+	"github.com/flowtemplates/flow-go/analyzer"
+	"github.com/flowtemplates/flow-go/lexer"
+	"github.com/flowtemplates/flow-go/parser"
+	"github.com/flowtemplates/flow-go/renderer"
+	"github.com/flowtemplates/flow-go/types"
+	"github.com/flowtemplates/flow-go/value"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		scope    renderer.Input
+		expected string
+	}{
+		{
+			name:     "Text",
+			input:    "Hello world",
+			expected: "Hello world",
+		},
+		{
+			name:     "Filters",
+			input:    "Hello {{ name -> upper }}!",
+			scope:    renderer.Input{"name": "world"},
+			expected: "Hello WORLD!",
+		},
+		{
+			name:     "Loop",
+			input:    "{% for i, item in items %}{{ i }}{{ item.name }}{% end %}",
+			scope:    renderer.Input{"items": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}},
+			expected: "0a1b",
+		},
+		{
+			name:     "Number condition printed",
+			input:    "{% if count > 0 %}{{ count }} items{% end %}",
+			scope:    renderer.Input{"count": 3},
+			expected: "3 items",
+		},
+		{
+			name:     "Printed var used as condition",
+			input:    "{{ name }}{% if name %}!{% end %}",
+			scope:    renderer.Input{"name": "world"},
+			expected: "world!",
+		},
+		{
+			name:     "Printed var used as number",
+			input:    "{{ n }} {{ n + 1 }}",
+			scope:    renderer.Input{"n": 1},
+			expected: "1 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := flow.Compile([]byte(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, tc.scope); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tc.expected {
+				t.Errorf("Input: %q\nMismatch.\nExpected:\n%q\nGot:\n%q", tc.input, tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	_, err := flow.Compile([]byte("{{ a + }}"))
+
+	var parseErrs parser.ErrorList
+	if !errors.As(err, &parseErrs) {
+		t.Errorf("Expected parser.ErrorList, got %T: %v", err, err)
+	}
+
+	_, err = flow.Compile([]byte("{{ a -> unknown }}"))
+
+	var typeErrs analyzer.TypeErrors
+	if !errors.As(err, &typeErrs) {
+		t.Errorf("Expected analyzer.TypeErrors, got %T: %v", err, err)
+	}
+}
+
+func TestTemplateTypeMap(t *testing.T) {
+	tmpl, err := flow.Compile([]byte("{% if enabled %}{{ user.name }}{% end %}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := tmpl.TypeMap()
+	if tm["enabled"] != types.Boolean {
+		t.Errorf("Expected enabled to be boolean, got %v", tm["enabled"])
+	}
+
+	if _, ok := tm["user"].(types.ObjectType); !ok {
+		t.Errorf("Expected user to be object, got %v", tm["user"])
+	}
+
+	// Changes of the returned map don't affect the template
+	delete(tm, "enabled")
+
+	if _, ok := tmpl.TypeMap()["enabled"]; !ok {
+		t.Error("TypeMap of the template was changed")
+	}
+}
+
+func TestCompileWithFilters(t *testing.T) {
+	filters := renderer.DefaultFilters()
+	filters["shout"] = renderer.Filter{
+		Input:  types.String,
+		Output: types.String,
+		Func: func(v value.Valuable, _ []value.Valuable) (value.Valuable, error) {
+			return value.StringValue(v.AsString() + "!"), nil
+		},
+	}
+
+	env := &renderer.Environment{Filters: filters}
+
+	tmpl, err := flow.CompileWith(env, []byte("{{ a -> shout }}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Filters are resolved on compilation
+	delete(env.Filters, "shout")
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, renderer.Input{"a": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "hi!" {
+		t.Errorf("Expected %q, got %q", "hi!", buf.String())
+	}
+}
+
+func TestCompileWithDelims(t *testing.T) {
+	env := &renderer.Environment{Delims: lexer.Delims{LExpr: "[[", RExpr: "]]"}}
+
+	tmpl, err := flow.CompileWith(env, []byte("{{ a }}=[[ a ]]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, renderer.Input{"a": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "{{ a }}=hi" {
+		t.Errorf("Expected %q, got %q", "{{ a }}=hi", buf.String())
+	}
+
+	_, err = flow.CompileWith(&renderer.Environment{Delims: lexer.Delims{LExpr: "{%"}}, []byte("a"))
+
+	var delimsErr *lexer.DelimsError
+	if !errors.As(err, &delimsErr) {
+		t.Errorf("Expected DelimsError, got %v", err)
+	}
+}
+
+func TestExecuteConcurrently(t *testing.T) {
+	tmpl, err := flow.Compile([]byte(
+		"{% let n = name -> upper %}{% for item in items %}{{ n }}{{ item }};{% end %}",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []any{1, 2, 3}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 100)
+
+	for i := range 100 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			name := fmt.Sprintf("n%d", i)
+			input := renderer.Input{"name": name, "items": items}
+
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, input); err != nil {
+				errs <- err
+
+				return
+			}
+
+			n := strings.ToUpper(name)
+			if expected := n + "1;" + n + "2;" + n + "3;"; buf.String() != expected {
+				errs <- fmt.Errorf("expected %q, got %q", expected, buf.String())
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// countingLoader counts loads of each template.
+type countingLoader struct {
+	renderer.MapLoader
+
+	mu    sync.Mutex
+	loads map[string]int
+}
+
+func (l *countingLoader) Load(name string) ([]byte, error) {
+	l.mu.Lock()
+	l.loads[name]++
+	l.mu.Unlock()
+
+	return l.MapLoader.Load(name)
+}
+
+func TestExecuteExtendParsesOnce(t *testing.T) {
+	loader := &countingLoader{
+		MapLoader: renderer.MapLoader{
+			"base.flow":   `{% extend "layout.flow" %}{% block body %}base{% end %}`,
+			"layout.flow": "<{% block body %}{% end %}>",
+		},
+		loads: make(map[string]int),
+	}
+
+	tmpl, err := flow.CompileWith(&renderer.Environment{Loader: loader}, []byte(
+		`{% extend "base.flow" %}{% block body %}{{ name }}{% end %}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	execute := func() {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, renderer.Input{"name": "child"}); err != nil {
+			t.Error(err)
+		}
+
+		if buf.String() != "<child>" {
+			t.Errorf("Expected %q, got %q", "<child>", buf.String())
+		}
+	}
+
+	execute()
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			execute()
+		}()
+	}
+
+	wg.Wait()
+
+	if expected := map[string]int{"base.flow": 1, "layout.flow": 1}; !maps.Equal(loader.loads, expected) {
+		t.Errorf("Expected loads %v, got %v", expected, loader.loads)
+	}
+}
+
+func TestExecuteContextLimits(t *testing.T) {
+	env := &renderer.Environment{
+		Limits: renderer.Limits{MaxOutputBytes: 4},
+	}
+
+	tmpl, err := flow.CompileWith(env, []byte("{{ name }}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	err = tmpl.ExecuteContext(context.Background(), &buf, renderer.Input{"name": "template"})
+	if !errors.Is(err, renderer.ErrOutputLimit) {
+		t.Errorf("Expected output limit error, got %v", err)
+	}
+
+	if buf.String() != "temp" {
+		t.Errorf("Expected %q, got %q", "temp", buf.String())
+	}
+}
@@ -2,15 +2,37 @@ package token
 
 import (
 	"fmt"
+	"unicode/utf16"
 )
 
+// Position in the source. Column is counted in characters, UTF16Column
+// in UTF-16 code units, as editors count them, Offset in bytes.
 // TODO: write equal function for ast
 type Position struct {
-	Line   int `json:"-"`
-	Column int `json:"-"`
-	Offset int `json:"-"`
+	Line        int `json:"-"`
+	Column      int `json:"-"`
+	UTF16Column int `json:"-"`
+	Offset      int `json:"-"`
 }
 
 func (p Position) String() string {
 	return fmt.Sprintf("%d:%d:%d", p.Line, p.Column, p.Offset)
 }
+
+// Advance returns position right after the text s starting at p.
+func (p Position) Advance(s string) Position {
+	for _, r := range s {
+		if r == '\n' {
+			p.Line++
+			p.Column = 1
+			p.UTF16Column = 1
+		} else {
+			p.Column++
+			p.UTF16Column += utf16.RuneLen(r)
+		}
+	}
+
+	p.Offset += len(s)
+
+	return p
+}
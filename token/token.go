@@ -3,6 +3,8 @@ package token
 import (
 	"fmt"
 	"slices"
+	"strings"
+	"unicode"
 )
 
 type Kind int
@@ -29,19 +31,34 @@ func (k Kind) IsValueable() bool {
 
 func (k Kind) IsComparasionOp() bool {
 	return (comparison_op_beg < k && k < comparison_op_end) ||
-		(AND < k && k < ISNOT)
+		(AND < k && k <= ISNOT)
 }
 
 func (k Kind) IsLogicalOp() bool {
 	return k.IsOneOfMany(AND, LAND, OR, LOR)
 }
 
+func (k Kind) IsKeyword() bool {
+	return keyword_beg < k && k < keyword_end && k != operator_end
+}
+
+// IsOperator reports whether k is an operator or a punctuation mark,
+// including the keyword operators, e.g. 'and'.
+func (k Kind) IsOperator() bool {
+	return operator_beg < k && k < operator_end
+}
+
+func (k Kind) IsArithmeticOp() bool {
+	return k.IsOneOfMany(ADD, MINUS, MUL, DIV, MOD)
+}
+
 const (
 	EOF Kind = iota
 	ILLEGAL
 
 	valuable_beg
 	COMM_TEXT
+	RAW_TEXT
 	LNBR
 	TEXT
 	WS
@@ -61,7 +78,7 @@ const (
 	// Operators and delimiters
 	RARR // ->
 
-	// ASSIGN     // =
+	ASSIGN // =
 	// ADD_ASSIGN // +=
 	// SUB_ASSIGN // -=
 	// MUL_ASSIGN // *=
@@ -69,10 +86,10 @@ const (
 	// REM_ASSIGN // %=
 
 	MINUS // -
-	// ADD // +
-	// MUL // *
-	// DIV // /
-	// MOD // %
+	ADD   // +
+	MUL   // *
+	DIV   // /
+	MOD   // %
 
 	LEXPR // {{
 	REXPR // }}
@@ -118,6 +135,7 @@ const (
 
 	operator_end
 	FOR     // for
+	IN      // in
 	LET     // let
 	IF      // if
 	GENIF   // genif
@@ -128,6 +146,8 @@ const (
 	DO      // do
 	DEFAULT // default
 	EXTEND  // extend
+	BLOCK   // block
+	RAW     // raw
 	keyword_end
 )
 
@@ -136,6 +156,7 @@ var tokens = []string{
 	ILLEGAL: "ILLEGAL",
 
 	COMM_TEXT: "COMMENT",
+	RAW_TEXT:  "RAW_TEXT",
 	TEXT:      "TEXT",
 	LNBR:      "LBR",
 	WS:        "WHITESPACE",
@@ -151,12 +172,12 @@ var tokens = []string{
 	COLON:    ":",
 
 	MINUS: "-",
-	// ADD: "+",
-	// MUL: "*",
-	// DIV: "/",
-	// MOD: "%",
+	ADD:   "+",
+	MUL:   "*",
+	DIV:   "/",
+	MOD:   "%",
 
-	// ASSIGN:     "=",
+	ASSIGN: "=",
 	// ADD_ASSIGN: "+=",
 	// SUB_ASSIGN: "-=",
 	// MUL_ASSIGN: "*=",
@@ -194,6 +215,7 @@ var tokens = []string{
 	RARR: "->",
 
 	FOR:     "for",
+	IN:      "in",
 	LET:     "let",
 	IF:      "if",
 	GENIF:   "genif",
@@ -203,6 +225,8 @@ var tokens = []string{
 	CASE:    "case",
 	DEFAULT: "default",
 	EXTEND:  "extend",
+	BLOCK:   "block",
+	RAW:     "raw",
 	AND:     "and",
 	OR:      "or",
 	IS:      "is",
@@ -214,14 +238,39 @@ var tokens = []string{
 const (
 	SQUOTE rune = '\''
 	DQUOTE rune = '"'
+	BQUOTE rune = '`'
 )
 
+// TrimMarker follows the opening delimiter or precedes the closing one,
+// e.g. '{%-' or '-}}', to trim whitespace on that side of the tag.
+const TrimMarker = '-'
+
 type Token struct {
 	Kind
 	Val string
 	Pos Position
 }
 
+// HasTrimMarker reports whether the token is a delimiter of the tag with
+// the whitespace trim marker.
+func (t Token) HasTrimMarker() bool {
+	switch t.Kind { //nolint: exhaustive
+	case LEXPR, LSTMT:
+		return strings.HasSuffix(t.Val, string(TrimMarker))
+
+	case REXPR, RSTMT:
+		return strings.HasPrefix(t.Val, string(TrimMarker))
+
+	default:
+		return false
+	}
+}
+
+// End returns the position right after the token.
+func (t Token) End() Position {
+	return t.Pos.Advance(t.Val)
+}
+
 func (t Token) String() string {
 	if t.IsValueable() {
 		switch t.Kind {
@@ -281,6 +330,18 @@ func GetKeywords() []Kind {
 	return res
 }
 
+// IsIdentStart reports whether the identifier may start with r: a letter,
+// a letter number or '_', as in Go and UAX #31.
+func IsIdentStart(r rune) bool {
+	return r == '_' || unicode.In(r, unicode.L, unicode.Nl)
+}
+
+// IsIdentPart reports whether r may be a part of the identifier: besides
+// characters it may start with, a digit, a combining mark or a connector.
+func IsIdentPart(r rune) bool {
+	return IsIdentStart(r) || unicode.In(r, unicode.Nd, unicode.Mn, unicode.Mc, unicode.Pc)
+}
+
 func IsNotOp(r rune) bool {
 	for i := operator_beg + 1; i < keyword_beg; i++ {
 		t := i.String()
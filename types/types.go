@@ -1,10 +1,15 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
 
 type Type interface {
 	t()
-	// String() string
+	String() string
 	// For unification
 	// apply(subst map[string]Type) Type
 	// ftv() map[string]bool
@@ -18,15 +23,32 @@ const (
 	Boolean PrimitiveType = "boolean"
 	// TODO: move to separate struct or remove completely
 	Any PrimitiveType = "any"
-	// TypeArray   Type = "array"
-	// TypeObject  Type = "object"
 )
 
+// IsValid reports whether val can be used as a value of type t.
+func IsValid(t Type, val any) bool {
+	switch t := t.(type) {
+	case PrimitiveType:
+		return t.IsValid(val)
+
+	case ListType:
+		return t.IsValid(val)
+
+	case ObjectType:
+		return t.IsValid(val)
+
+	default:
+		return true
+	}
+}
+
 func (t PrimitiveType) t() {}
 
-func (t PrimitiveType) IsValid(val any) bool {
-	fmt.Printf("%T, %s\n", val, val)
+func (t PrimitiveType) String() string {
+	return string(t)
+}
 
+func (t PrimitiveType) IsValid(val any) bool {
 	switch t {
 	case Number:
 		switch val.(type) {
@@ -73,3 +95,127 @@ func (t PrimitiveType) GetDefaultValue() string {
 type VarType string
 
 func (t VarType) t() {}
+
+// String returns the name of the variable the type is bound to.
+func (t VarType) String() string {
+	return string(t)
+}
+
+// ListType is a type of ordered collections whose elements share Elem type.
+type ListType struct {
+	Elem Type
+}
+
+func (t ListType) t() {}
+
+// String returns the type as '[]elem'.
+func (t ListType) String() string {
+	if t.Elem == nil {
+		return "[]" + Any.String()
+	}
+
+	return "[]" + t.Elem.String()
+}
+
+func (t ListType) IsValid(val any) bool {
+	rv := indirect(reflect.ValueOf(val))
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return false
+	}
+
+	for i := range rv.Len() {
+		if !IsValid(t.Elem, rv.Index(i).Interface()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ObjectType is a type of structured values with named Fields,
+// e.g. maps with string keys and structs.
+type ObjectType struct {
+	Fields map[string]Type
+}
+
+func (t ObjectType) t() {}
+
+// String returns the type as '{name: type, ...}' with fields sorted by name.
+func (t ObjectType) String() string {
+	names := make([]string, 0, len(t.Fields))
+	for name := range t.Fields {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = name + ": " + t.Fields[name].String()
+	}
+
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func (t ObjectType) IsValid(val any) bool {
+	rv := indirect(reflect.ValueOf(val))
+
+	fields := make(map[string]any)
+
+	switch rv.Kind() { //nolint: exhaustive
+	case reflect.Map:
+		// Keys are formatted as when the value is converted for rendering
+		for iter := rv.MapRange(); iter.Next(); {
+			fields[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+
+	case reflect.Struct:
+		for i := range rv.NumField() {
+			if name, ok := FieldName(rv.Type().Field(i)); ok {
+				fields[name] = rv.Field(i).Interface()
+			}
+		}
+
+	default:
+		return false
+	}
+
+	for name, typ := range t.Fields {
+		field, ok := fields[name]
+		if !ok || !IsValid(typ, field) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FieldName returns the name struct field is accessed by in templates:
+// name from the json tag if it is set, or the name of the field otherwise.
+func FieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	switch name {
+	case "-":
+		return "", false
+
+	case "":
+		return field.Name, true
+
+	default:
+		return name, true
+	}
+}
+
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+
+	return rv
+}
@@ -2,8 +2,12 @@ package value
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/flowtemplates/flow-go/types"
 )
@@ -13,37 +17,143 @@ type Valuable interface {
 	AsBoolean() bool
 	AsNumber() float64
 	Add(value Valuable) Valuable
-	Type() types.PrimitiveType
+	Type() types.Type
 }
 
-func FromAny(value any) Valuable {
+// FromAny converts the Go value to the template value, structured values
+// are converted recursively. Map keys that are not strings are formatted
+// with fmt.Sprint. It fails on values that can't be represented, e.g.
+// functions and channels, and on values referencing themselves.
+func FromAny(value any) (Valuable, error) {
+	c := converter{seen: make(map[reference]struct{})}
+
+	return c.convert(value)
+}
+
+// reference identifies a pointer, map or slice being converted.
+type reference struct {
+	ptr uintptr
+	typ reflect.Type
+	// Slices of different length may share the same array
+	len int
+}
+
+type converter struct {
+	// References on the path from the root to the current value
+	seen map[reference]struct{}
+}
+
+func (c converter) convert(value any) (Valuable, error) {
 	switch v := value.(type) {
 	case string:
-		return StringValue(v)
-
-	case *string:
-		return StringValue(*v)
+		return StringValue(v), nil
 
 	case float64:
-		return NumberValue(v)
-
-	case *float64:
-		return NumberValue(*v)
+		return NumberValue(v), nil
 
 	case int:
-		return NumberValue(v)
-
-	case *int:
-		return NumberValue(*v)
+		return NumberValue(v), nil
 
 	case bool:
-		return BooleanValue(v)
+		return BooleanValue(v), nil
+
+	case Valuable:
+		return v, nil
+
+	case nil:
+		return NullValue{}, nil
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() { //nolint: exhaustive
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			break
+		}
+
+		ref := reference{ptr: rv.Pointer(), typ: rv.Type()}
+		if rv.Kind() == reflect.Slice {
+			ref.len = rv.Len()
+		}
+
+		if _, ok := c.seen[ref]; ok {
+			return nil, fmt.Errorf("cannot convert value of type %T: it references itself", value)
+		}
+
+		c.seen[ref] = struct{}{}
+		defer delete(c.seen, ref)
+	}
+
+	switch rv.Kind() { //nolint: exhaustive
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return NullValue{}, nil
+		}
+
+		return c.convert(rv.Elem().Interface())
 
-	case *bool:
-		return BooleanValue(*v)
+	case reflect.String:
+		return StringValue(rv.String()), nil
+
+	case reflect.Bool:
+		return BooleanValue(rv.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NumberValue(rv.Int()), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NumberValue(rv.Uint()), nil
+
+	case reflect.Float32, reflect.Float64:
+		return NumberValue(rv.Float()), nil
+
+	case reflect.Slice, reflect.Array:
+		list := make(ListValue, rv.Len())
+		for i := range rv.Len() {
+			v, err := c.convert(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			list[i] = v
+		}
+
+		return list, nil
+
+	case reflect.Map:
+		m := make(MapValue, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			v, err := c.convert(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			m[fmt.Sprint(iter.Key().Interface())] = v
+		}
+
+		return m, nil
+
+	case reflect.Struct:
+		m := make(MapValue, rv.NumField())
+		for i := range rv.NumField() {
+			name, ok := types.FieldName(rv.Type().Field(i))
+			if !ok {
+				continue
+			}
+
+			v, err := c.convert(rv.Field(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			m[name] = v
+		}
+
+		return m, nil
 
 	default:
-		panic(fmt.Sprintf("cannot convert any to Valuable: unsupported type: %T", value))
+		return nil, fmt.Errorf("cannot convert value of type %T", value)
 	}
 }
 
@@ -74,7 +184,7 @@ func (v StringValue) Add(b Valuable) Valuable {
 	return StringValue(string(v) + b.AsString())
 }
 
-func (v StringValue) Type() types.PrimitiveType {
+func (v StringValue) Type() types.Type {
 	return types.String
 }
 
@@ -106,7 +216,7 @@ func (v BooleanValue) Add(b Valuable) Valuable {
 	}
 }
 
-func (v BooleanValue) Type() types.PrimitiveType {
+func (v BooleanValue) Type() types.Type {
 	return types.Boolean
 }
 
@@ -139,6 +249,103 @@ func (v NumberValue) Add(b Valuable) Valuable {
 	}
 }
 
-func (v NumberValue) Type() types.PrimitiveType {
+func (v NumberValue) Type() types.Type {
 	return types.Number
 }
+
+type ListValue []Valuable
+
+func (v ListValue) AsString() string {
+	items := make([]string, len(v))
+	for i, item := range v {
+		items[i] = item.AsString()
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (v ListValue) AsBoolean() bool {
+	return len(v) != 0
+}
+
+func (v ListValue) AsNumber() float64 {
+	return float64(len(v))
+}
+
+func (v ListValue) Add(b Valuable) Valuable {
+	if list, ok := b.(ListValue); ok {
+		return append(append(ListValue{}, v...), list...)
+	}
+
+	return StringValue(v.AsString() + b.AsString())
+}
+
+func (v ListValue) Type() types.Type {
+	if len(v) == 0 {
+		return types.ListType{Elem: types.Any}
+	}
+
+	return types.ListType{Elem: v[0].Type()}
+}
+
+type MapValue map[string]Valuable
+
+func (v MapValue) AsString() string {
+	items := make([]string, 0, len(v))
+	for _, key := range slices.Sorted(maps.Keys(v)) {
+		items = append(items, key+": "+v[key].AsString())
+	}
+
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+func (v MapValue) AsBoolean() bool {
+	return len(v) != 0
+}
+
+func (v MapValue) AsNumber() float64 {
+	return float64(len(v))
+}
+
+func (v MapValue) Add(b Valuable) Valuable {
+	if m, ok := b.(MapValue); ok {
+		res := maps.Clone(v)
+		maps.Copy(res, m)
+
+		return res
+	}
+
+	return StringValue(v.AsString() + b.AsString())
+}
+
+func (v MapValue) Type() types.Type {
+	fields := make(map[string]types.Type, len(v))
+	for name, field := range v {
+		fields[name] = field.Type()
+	}
+
+	return types.ObjectType{Fields: fields}
+}
+
+// NullValue is a value of nil and nil pointers.
+type NullValue struct{}
+
+func (v NullValue) AsString() string {
+	return ""
+}
+
+func (v NullValue) AsBoolean() bool {
+	return false
+}
+
+func (v NullValue) AsNumber() float64 {
+	return 0
+}
+
+func (v NullValue) Add(b Valuable) Valuable {
+	return b
+}
+
+func (v NullValue) Type() types.Type {
+	return types.Any
+}